@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// autoKillIdle kills panes that have shown no new output for longer than the
+// auto_kill_idle_after setting. It never touches panes that are busy, waiting
+// on the user, or whose last activity is unknown, and logs every kill to
+// auto-kill.log in the state directory. Returns the ids of the killed panes.
+func autoKillIdle(panes []Pane, now time.Time) map[string]bool {
+	setting := config.Get().AutoKillIdleAfter
+	if setting == "" {
+		return nil
+	}
+	after, err := time.ParseDuration(setting)
+	if err != nil || after <= 0 {
+		debugf("auto_kill_idle_after: invalid duration %q", setting)
+		return nil
+	}
+
+	var killed map[string]bool
+	for _, p := range panes {
+		// Unread panes (finished, never looked at) are fair game: that is
+		// what an abandoned agent looks like.
+		switch {
+		case p.Status == StatusBusy, p.Status == StatusNeedsAttention, p.Status == StatusNeedsAuth:
+			continue
+		case p.HeuristicBusy, p.HeuristicAttention, p.InMode:
+			continue
+		}
+		if p.LastActive.IsZero() || now.Sub(p.LastActive) < after {
+			continue
+		}
+		err := KillPane(p.Target)
+		logAutoKill("killed %s %s (%s) in %s, idle %s: err=%v",
+			p.Provider, p.Target, p.PaneID, p.Path, now.Sub(p.LastActive).Round(time.Minute), err)
+		if err == nil {
+			if killed == nil {
+				killed = make(map[string]bool)
+			}
+			killed[p.PaneID] = true
+		}
+	}
+	return killed
+}
+
+func logAutoKill(format string, args ...any) {
+	f, err := os.OpenFile(stateFile("auto-kill.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	log.New(f, "", log.LstdFlags).Printf(format, args...)
+}
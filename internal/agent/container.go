@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+// containerCommands are pane commands that can wrap an agent running inside
+// a container (docker exec, devcontainer exec, ...), where the host process
+// table can't see it.
+var containerCommands = []string{"docker", "podman", "nerdctl", "devcontainer"}
+
+// containerAgents remembers which provider was recognized in each container
+// pane, keyed by pane id. An idle agent shows none of the indicators it is
+// recognized by, so a pane stays listed once identified, for as long as its
+// command is still the container one. Panes where no agent was recognized
+// are remembered too, so a plain docker shell isn't captured every tick.
+var containerAgents = struct {
+	sync.Mutex
+	byPane map[string]string
+	misses map[string]containerMiss
+}{byPane: make(map[string]string), misses: make(map[string]containerMiss)}
+
+// containerMiss is a container pane whose screen showed no agent.
+type containerMiss struct {
+	hash    [sha256.Size]byte // of the screen that was checked
+	checked time.Time
+}
+
+// containerRecheck is how long a container pane with no agent on screen
+// goes before it is captured again.
+const containerRecheck = 10 * time.Second
+
+// containerAgent returns the provider running in r, a pane whose command is a
+// container command, by looking for a provider's busy indicator, status
+// label or completion summary on its screen. Returns "" for other panes and
+// for container panes where no agent has been seen yet.
+func containerAgent(r rawPane) string {
+	if !slices.Contains(containerCommands, filepath.Base(r.cmd)) {
+		return ""
+	}
+	containerAgents.Lock()
+	name, ok := containerAgents.byPane[r.paneID]
+	miss, missed := containerAgents.misses[r.paneID]
+	containerAgents.Unlock()
+	if ok {
+		return name
+	}
+	if missed && time.Since(miss.checked) < containerRecheck {
+		return ""
+	}
+
+	out, err := tmuxOutput("capture-pane", "-t", r.target, "-p", "-S", "-20")
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(out)
+	if !missed || hash != miss.hash {
+		name = provider.FromScreen(normalizeLines(string(out)))
+	}
+	containerAgents.Lock()
+	defer containerAgents.Unlock()
+	if name == "" {
+		containerAgents.misses[r.paneID] = containerMiss{hash: hash, checked: time.Now()}
+		return ""
+	}
+	debugf("%s: recognized %s running under %s", r.target, name, r.cmd)
+	containerAgents.byPane[r.paneID] = name
+	delete(containerAgents.misses, r.paneID)
+	return name
+}
+
+// forgetContainerAgents drops remembered container agents whose panes are
+// gone or no longer run a container command.
+func forgetContainerAgents(raw []rawPane) {
+	still := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		if slices.Contains(containerCommands, filepath.Base(r.cmd)) {
+			still[r.paneID] = true
+		}
+	}
+	containerAgents.Lock()
+	defer containerAgents.Unlock()
+	for id := range containerAgents.byPane {
+		if !still[id] {
+			delete(containerAgents.byPane, id)
+		}
+	}
+	for id := range containerAgents.misses {
+		if !still[id] {
+			delete(containerAgents.misses, id)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainerAgent(t *testing.T) {
+	screens := map[string]string{
+		"dev:1.0": "$ docker exec -it dev codex\n• Working (8s • Esc to interrupt)\n",
+		"dev:2.0": "$ docker exec -it dev bash\nroot@3f2a1c:/workspace# ls\ngo.mod  main.go\n",
+		"dev:3.0": "⏺ Done.\n\n✻ Cooked for 45s\n",
+	}
+	f := &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+		for target, screen := range screens {
+			if strings.HasPrefix(call, "capture-pane -t "+target+" ") {
+				return []byte(screen), nil
+			}
+		}
+		return nil, nil
+	}}
+	useFake(t, f)
+	t.Cleanup(func() { forgetContainerAgents(nil) })
+
+	raw := []rawPane{
+		{paneID: "%1", target: "dev:1.0", cmd: "docker"},
+		{paneID: "%2", target: "dev:2.0", cmd: "docker"},
+		{paneID: "%3", target: "dev:3.0", cmd: "/usr/bin/podman"},
+		{paneID: "%4", target: "dev:4.0", cmd: "zsh"},
+	}
+	want := []string{"codex", "", "claude", ""}
+	for i, r := range raw {
+		if got := containerAgent(r); got != want[i] {
+			t.Errorf("containerAgent(%s running %s) = %q, want %q", r.target, r.cmd, got, want[i])
+		}
+	}
+	if n := len(commandsLike(f, "capture-pane -t dev:4.0")); n != 0 {
+		t.Errorf("captured a pane that runs no container command")
+	}
+
+	// Neither a recognized agent nor a docker shell with no agent is
+	// captured again on the next tick.
+	f.calls = nil
+	for i, r := range raw {
+		if got := containerAgent(r); got != want[i] {
+			t.Errorf("second tick: containerAgent(%s) = %q, want %q", r.target, got, want[i])
+		}
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("second tick ran %q, want no captures", f.calls)
+	}
+}
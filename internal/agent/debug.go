@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+var (
+	debugOnce sync.Once
+	debugLog  *log.Logger
+)
+
+// debugf appends a line to the file named by AGENTMUX_DEBUG, if set. The TUI
+// owns the terminal, so diagnostics go to a file instead.
+func debugf(format string, args ...any) {
+	debugOnce.Do(func() {
+		path := os.Getenv("AGENTMUX_DEBUG")
+		if path == "" {
+			return
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return
+		}
+		debugLog = log.New(f, "", log.LstdFlags|log.Lmicroseconds)
+	})
+	if debugLog != nil {
+		debugLog.Printf(format, args...)
+	}
+}
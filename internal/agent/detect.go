@@ -0,0 +1,318 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+// detection is what the heuristics derive from a pane's captured content.
+type detection struct {
+	attention, busy, auth, done, contextLow bool
+	sessionID                               string
+	attentionLine                           string   // last line that triggered attention
+	lines                                   []string // normalized capture, for the status label
+}
+
+// detectCache holds the last detection per pane id. Most panes sit unchanged
+// between ticks; reusing the result for an identical capture skips the
+// regexes and provider checks, and for a window with no output since the
+// capture (see reuseDetection) the capture itself.
+var detectCache = struct {
+	sync.Mutex
+	byPane map[string]cachedDetection
+}{byPane: make(map[string]cachedDetection)}
+
+type cachedDetection struct {
+	hash, provider, args string
+	activity, captured   int64 // window_activity when listed and unix second the capture started; 0 if unknown
+	detection
+}
+
+// Detect sets ContentHash and the heuristic fields (attention, busy, auth,
+// completion, low context, session id) of p from content, its captured
+// screen without trailing newlines. The status label waits for the status;
+// see labelStatus. Detection is skipped when the
+// content, provider and args are the same as on the pane's previous call.
+func Detect(p *Pane, content []byte) {
+	h := sha256.Sum256(content)
+	p.ContentHash = fmt.Sprintf("%x", h[:8])
+
+	detectCache.Lock()
+	c, ok := detectCache.byPane[p.PaneID]
+	detectCache.Unlock()
+	if !ok || c.hash != p.ContentHash || c.provider != p.Provider || c.args != p.Args {
+		c = cachedDetection{hash: p.ContentHash, provider: p.Provider, args: p.Args, detection: detect(p, content)}
+		detectCache.Lock()
+		detectCache.byPane[p.PaneID] = c
+		detectCache.Unlock()
+	}
+
+	applyDetection(p, c.detection)
+}
+
+// applyDetection sets the heuristic fields of p from d.
+func applyDetection(p *Pane, d detection) {
+	p.HeuristicAttention = d.attention
+	p.HeuristicBusy = d.busy
+	p.HeuristicAuth = d.auth
+	p.HeuristicDone = d.done
+	p.ContextLow = d.contextLow
+	p.SessionID = d.sessionID
+	p.AttentionLine = d.attentionLine
+	p.lines = d.lines
+	if p.BlockedBy != "" {
+		// The agent can't continue until the user quits the pager or
+		// editor, and its own screen is hidden anyway.
+		p.HeuristicAttention = true
+		p.HeuristicBusy = false
+		p.AttentionLine = ""
+		p.StatusLabel = "in pager"
+		if p.BlockedBy == "editor" {
+			p.StatusLabel = "editor open"
+		}
+	}
+}
+
+// reuseDetection applies p's cached detection without capturing again when
+// nothing was written to its window since the cached capture started, and
+// reports whether it did. window_activity only has second resolution, so
+// output in the same second as the capture always gets a fresh one.
+func reuseDetection(p *Pane) bool {
+	if p.Activity == 0 {
+		return false
+	}
+	detectCache.Lock()
+	c, ok := detectCache.byPane[p.PaneID]
+	detectCache.Unlock()
+	if !ok || c.activity != p.Activity || c.activity >= c.captured || c.provider != p.Provider || c.args != p.Args {
+		return false
+	}
+	p.ContentHash = c.hash
+	applyDetection(p, c.detection)
+	return true
+}
+
+// noteCapture records that p's cached detection comes from a capture started
+// at the unix second captured, with the window activity p was listed with.
+func noteCapture(p *Pane, captured int64) {
+	detectCache.Lock()
+	defer detectCache.Unlock()
+	if c, ok := detectCache.byPane[p.PaneID]; ok {
+		c.activity, c.captured = p.Activity, captured
+		detectCache.byPane[p.PaneID] = c
+	}
+}
+
+func detect(p *Pane, content []byte) detection {
+	captured := normalizeLines(string(content))
+	// Lines captured only for the busy check are left out of the others.
+	lines := captured[min(busyOnlyLines(p), len(captured)):]
+	// What the user is typing in the input line isn't the agent asking.
+	output := slices.DeleteFunc(slices.Clone(lines), func(l string) bool {
+		return provider.IsInputLine(p.Provider, l)
+	})
+	outputJoined := strings.Join(output, "\n")
+	d := detection{
+		attention: promptRe.MatchString(outputJoined) || provider.NeedsAttention(p.Provider, output) ||
+			(provider.UsesQuestionHeuristic(p.Provider) && questionRe.MatchString(outputJoined)),
+		busy:       provider.IsBusy(p.Provider, captured[busyRange(p.Provider, captured):]),
+		auth:       authRe.MatchString(strings.Join(output[tailStart(output, authWindow):], "\n")) || provider.NeedsAuth(p.Provider, lines),
+		done:       provider.JustCompleted(p.Provider, lines),
+		contextLow: provider.LowContext(p.Provider, lines),
+		sessionID:  provider.SessionID(p.Provider, lines, p.Args),
+		lines:      lines,
+	}
+	if d.busy {
+		// A working agent isn't waiting for a login, whatever it prints.
+		d.auth = false
+	}
+	if d.attention {
+		d.attentionLine = attentionLine(p.Provider, output)
+	}
+	return d
+}
+
+// labelStatus sets p's StatusLabel to what its provider reads from the
+// captured lines for the status p settled on. A pane blocked on a pager or
+// editor keeps the label Detect gave it.
+func labelStatus(p *Pane) {
+	if p.BlockedBy != "" {
+		return
+	}
+	status := provider.StatusIdle
+	switch p.Status {
+	case StatusBusy:
+		status = provider.StatusBusy
+	case StatusNeedsAttention:
+		status = provider.StatusAttention
+	case StatusNeedsAuth:
+		status = provider.StatusAuth
+	}
+	p.StatusLabel = provider.StatusLabel(p.Provider, p.lines, status)
+}
+
+// busyOnlyLines returns how many leading lines of p's capture were taken only
+// because its provider's BusyScanLines asks for more than captureLines.
+func busyOnlyLines(p *Pane) int {
+	if fullCapture(p) {
+		return 0
+	}
+	return max(0, provider.BusyScanLines(p.Provider)-captureLines)
+}
+
+// authWindow is how many trailing non-blank lines the generic login checks
+// look at. A login prompt waits at the bottom of the screen; the same words
+// further up are the agent's output, e.g. code handling an invalid API key.
+// Providers' own login phrases are specific enough to match anywhere.
+const authWindow = 4
+
+// attentionLine returns the last of lines (input lines already removed)
+// that the attention checks match on its own, or "" when only the lines
+// together match.
+func attentionLine(name string, lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		l := lines[i]
+		if promptRe.MatchString(l) || provider.NeedsAttention(name, lines[i:i+1]) ||
+			(provider.UsesQuestionHeuristic(name) && questionRe.MatchString(l)) {
+			return l
+		}
+	}
+	return ""
+}
+
+// busyRange returns the index of the first line the named provider's busy
+// check looks at: the start of the last BusyWindow non-blank lines, or 0.
+func busyRange(name string, lines []string) int {
+	return tailStart(lines, provider.BusyWindow(name))
+}
+
+// tailStart returns the index of the first of the last n non-blank lines, or
+// 0 when n is 0 or there are no more than n.
+func tailStart(lines []string, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	start := len(lines)
+	for kept := 0; start > 0 && kept < n; start-- {
+		if lines[start-1] != "" {
+			kept++
+		}
+	}
+	return start
+}
+
+// forgetDetections drops cached detections for panes not in panes.
+func forgetDetections(panes []Pane) {
+	alive := make(map[string]bool, len(panes))
+	for _, p := range panes {
+		alive[p.PaneID] = true
+	}
+	detectCache.Lock()
+	defer detectCache.Unlock()
+	for id := range detectCache.byPane {
+		if !alive[id] {
+			delete(detectCache.byPane, id)
+		}
+	}
+}
+
+// ExplainDetection writes a report of what the heuristics find in content, a
+// captured screen, for the named provider ("" picks one from the screen, as
+// for container panes): each check, whether it fired, and the line and
+// pattern that triggered it.
+func ExplainDetection(w io.Writer, name string, content []byte) {
+	lines := normalizeLines(strings.TrimRight(string(content), "\n"))
+	if name == "" {
+		name = provider.FromScreen(lines)
+		if name == "" {
+			fmt.Fprintln(w, "provider:   none recognized (pass --provider)")
+		} else {
+			fmt.Fprintf(w, "provider:   %s (recognized from the screen)\n", name)
+		}
+	} else {
+		fmt.Fprintf(w, "provider:   %s\n", name)
+	}
+
+	// reportFrom prints the first line (from the bottom, down to index from)
+	// that matches.
+	reportFrom := func(check string, from int, match func(line string) string) {
+		for i := len(lines) - 1; i >= from; i-- {
+			if pat := match(lines[i]); pat != "" {
+				fmt.Fprintf(w, "%-11s yes, line %d: %q (matched %q)\n", check+":", i+1, lines[i], pat)
+				return
+			}
+		}
+		fmt.Fprintf(w, "%-11s no\n", check+":")
+	}
+	report := func(check string, match func(line string) string) {
+		reportFrom(check, 0, match)
+	}
+	one := func(l string) []string { return []string{l} }
+
+	// The busy check only sees the provider's busy window.
+	reportFrom("busy", busyRange(name, lines), func(l string) string {
+		if provider.IsBusy(name, one(l)) {
+			return "busy indicator"
+		}
+		return ""
+	})
+	report("prompt", func(l string) string {
+		if provider.IsInputLine(name, l) {
+			return ""
+		}
+		if m := promptRe.FindString(l); m != "" {
+			return m
+		}
+		if provider.NeedsAttention(name, one(l)) {
+			return "provider prompt"
+		}
+		return ""
+	})
+	if provider.UsesQuestionHeuristic(name) {
+		report("question", func(l string) string {
+			if provider.IsInputLine(name, l) {
+				return ""
+			}
+			return questionRe.FindString(l)
+		})
+	} else {
+		fmt.Fprintln(w, "question:   skipped (provider opts out)")
+	}
+	// The generic login patterns only count near the bottom of the screen.
+	authTail := lines[tailStart(lines, authWindow):]
+	report("auth", func(l string) string {
+		if m := authRe.FindString(l); m != "" && slices.Contains(authTail, l) && !provider.IsInputLine(name, l) {
+			return m
+		}
+		if provider.NeedsAuth(name, one(l)) {
+			return "provider login prompt"
+		}
+		return ""
+	})
+	report("done", func(l string) string {
+		if provider.JustCompleted(name, one(l)) {
+			return "completion summary"
+		}
+		return ""
+	})
+	report("low ctx", func(l string) string {
+		if provider.LowContext(name, one(l)) {
+			return "low-context warning"
+		}
+		return ""
+	})
+	report("label", func(l string) string {
+		for _, s := range []provider.Status{provider.StatusBusy, provider.StatusAttention, provider.StatusAuth, provider.StatusIdle} {
+			if label := provider.StatusLabel(name, one(l), s); label != "" {
+				return label + " when " + s.String()
+			}
+		}
+		return ""
+	})
+}
@@ -0,0 +1,451 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+// detectFrame runs Detect on frame, a captured screen, for a pane of the
+// named provider and returns the pane.
+func detectFrame(t *testing.T, name, frame string) Pane {
+	t.Helper()
+	p := Pane{PaneID: "%" + t.Name(), Provider: name}
+	Detect(&p, []byte(strings.Trim(frame, "\n")))
+	return p
+}
+
+func TestDetectAuthPrompts(t *testing.T) {
+	tests := []struct {
+		name, provider, frame string
+	}{
+		{"claude login", "claude", `
+ Claude Code can be used with your Claude subscription or billed based on API usage
+
+ Select login method:
+
+ ❯ 1. Claude account with subscription
+   2. Anthropic Console account
+   3. 3rd-party platform
+`},
+		{"codex sign in", "codex", `
+  Sign in with ChatGPT to use Codex as part of your paid plan
+  or connect an API key for usage-based billing
+
+> 1. Sign in with ChatGPT
+  2. Provide your own API key
+`},
+		{"generic api key", "kimi", `
+Welcome to kimi
+
+Please enter your API key to continue:
+`},
+		{"oauth url", "gemini", `
+Waiting for auth... (Press ESC to cancel)
+Open this URL in your browser: https://accounts.google.com/o/oauth2/v2/auth?client_id=681255809395
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := detectFrame(t, tt.provider, tt.frame); !p.HeuristicAuth {
+				t.Error("login prompt not detected")
+			}
+		})
+	}
+}
+
+func TestDetectAuthIgnoresOutput(t *testing.T) {
+	tests := []struct {
+		name, provider, frame string
+	}{
+		{"prose above the prompt", "claude", `
+⏺ The handler now returns 401 with "invalid api key" when the header is
+  missing, and the OAuth callback (https://example.com/oauth/callback) is
+  registered in the router.
+
+  Tests pass.
+
+╭──────────────────────────────────────╮
+│ >                                    │
+╰──────────────────────────────────────╯
+  ? for shortcuts
+`},
+		{"busy agent", "claude", `
+⏺ Checking where "Please log in" is rendered
+  Authentication required errors are mapped in errors.go
+  Not logged in -> redirect
+✻ Reading files… (12s · esc to interrupt)
+`},
+		{"typed into the input line", "codex", `
+  Worked for 1m 02s
+
+› paste your api key handling into the README
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := detectFrame(t, tt.provider, tt.frame); p.HeuristicAuth {
+				t.Error("agent output read as a login prompt")
+			}
+		})
+	}
+}
+
+// BenchmarkDetect runs Detect over captures of 200 mostly idle panes, one
+// tick per iteration: with every capture changed, so each heuristic runs, and
+// with the captures of the previous tick, as static panes see between ticks.
+func BenchmarkDetect(b *testing.B) {
+	providers := provider.All()
+	panes := make([]Pane, 200)
+	frames := make([][]byte, len(panes))
+	for i := range panes {
+		panes[i] = Pane{PaneID: fmt.Sprintf("%%%d", i), Provider: providers[i%len(providers)]}
+		var sb strings.Builder
+		for j := range 10 {
+			fmt.Fprintf(&sb, "│ line %d of pane %d: edited internal/agent/tmux.go, ran go test ./...   │\n", j, i)
+		}
+		sb.WriteString("> Would you like me to continue?")
+		frames[i] = []byte(sb.String())
+	}
+	tick := func() {
+		for i := range panes {
+			Detect(&panes[i], frames[i])
+		}
+	}
+	b.Run("changed", func(b *testing.B) {
+		for b.Loop() {
+			forgetDetections(nil)
+			tick()
+		}
+	})
+	b.Run("unchanged", func(b *testing.B) {
+		tick()
+		for b.Loop() {
+			tick()
+		}
+	})
+}
+
+func TestDetectQuestionHeuristicPerProvider(t *testing.T) {
+	// A question in the middle of an explanation, the agent back at its
+	// input line.
+	const frame = `
+  The cache key includes the args. Why? Shall I say it plainly: a resumed
+  session must not reuse the detection of the one it replaced.
+
+› 
+`
+	tests := []struct {
+		provider  string
+		attention bool
+	}{
+		{"claude", true},
+		{"codex", false},
+		{"gemini", false},
+		{"opencode", false},
+		{"kimi", false},
+	}
+	for _, tt := range tests {
+		if p := detectFrame(t, tt.provider, frame); p.HeuristicAttention != tt.attention {
+			t.Errorf("%s: attention = %v, want %v", tt.provider, p.HeuristicAttention, tt.attention)
+		}
+	}
+}
+
+func TestDetectBusyScanLines(t *testing.T) {
+	provider.RegisterCustom(provider.Spec{Name: "qwen", BusyIndicators: []string{"(esc to cancel"}, BusyScanLines: 20})
+	t.Cleanup(func() { provider.Unregister("qwen") })
+	// The capture of a qwen pane: 20 lines, its status bar 15 from the
+	// bottom, with a question from earlier output above it.
+	var frame strings.Builder
+	frame.WriteString("  Done with the client.\n\n  Retries are next.\n\n")
+	frame.WriteString("Would you like me to add the retry too?\n")
+	frame.WriteString("⠋ Refactoring the handler (esc to cancel, 42s)\n")
+	for i := range 14 {
+		fmt.Fprintf(&frame, "  %d | func handle(w http.ResponseWriter, r *http.Request) {\n", i+1)
+	}
+	p := detectFrame(t, "qwen", frame.String())
+	if !p.HeuristicBusy {
+		t.Error("busy indicator 15 lines up not seen")
+	}
+	if p.HeuristicAttention {
+		t.Error("attention from a line captured only for the busy check")
+	}
+}
+
+// Claude's busy state comes from its status line alone, so it is detected the
+// same way on Linux, where no caffeinate child exists, as on macOS.
+func TestDetectClaudeBusy(t *testing.T) {
+	tests := []struct {
+		name, frame string
+		busy        bool
+	}{
+		{"generating", `
+⏺ I'll add retries to the client.
+
+✶ Cogitating… (12s · ↑ 1.2k tokens · esc to interrupt)
+
+╭──────────────────────────────────────────────────────────╮
+│ >                                                        │
+╰──────────────────────────────────────────────────────────╯
+  ⏵⏵ accept edits on (shift+tab to cycle)
+`, true},
+		{"compacting", `
+✻ Compacting conversation… (34s · esc to interrupt)
+
+❯ 
+`, true},
+		{"idle at the prompt", `
+⏺ Added retries with exponential backoff to the client.
+
+✻ Worked for 1m 12s
+
+❯ 
+  ? for shortcuts
+`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := detectFrame(t, "claude", tt.frame); p.HeuristicBusy != tt.busy {
+				t.Errorf("busy = %v, want %v", p.HeuristicBusy, tt.busy)
+			}
+		})
+	}
+}
+
+func TestDetectCompletionSummary(t *testing.T) {
+	tests := []struct {
+		name, provider, frame string
+		done                  bool
+	}{
+		{"claude summary", "claude", `
+⏺ Added retries with exponential backoff to the client.
+
+✻ Worked for 2m 41s
+
+❯ 
+`, true},
+		{"claude summary in hours", "claude", `
+✻ Brewed for 1h 3m 12s
+
+❯ 
+`, true},
+		{"claude still working", "claude", `
+✶ Cogitating… (12s · ↑ 1.2k tokens · esc to interrupt)
+
+❯ 
+`, false},
+		{"claude summary quoted in output", "claude", `
+⏺ The log shows "✻ Worked for 2m" after each task.
+
+❯ 
+`, false},
+		{"codex summary", "codex", `
+• Updated the handler and added a test.
+
+─ Worked for 1m 05s ─────────────────────────────────
+
+› 
+`, true},
+		{"codex still working", "codex", `
+• Working (14s • Esc to interrupt)
+
+› 
+`, false},
+		{"provider without a summary", "kimi", `
+Worked for 2m
+
+> 
+`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := detectFrame(t, tt.provider, tt.frame); p.HeuristicDone != tt.done {
+				t.Errorf("done = %v, want %v", p.HeuristicDone, tt.done)
+			}
+		})
+	}
+}
+
+func TestDetectLowContext(t *testing.T) {
+	tests := []struct {
+		name, provider, frame string
+		low                   bool
+	}{
+		{"claude auto-compact warning", "claude", `
+⏺ Updated the handler.
+
+❯ 
+  ⏵⏵ accept edits on (shift+tab to cycle)      Context left until auto-compact: 8%
+`, true},
+		{"claude context low", "claude", `
+❯ 
+  Context low (3% remaining) · Run /compact to compact & continue
+`, true},
+		{"claude plenty of context", "claude", `
+⏺ Updated the handler.
+
+❯ 
+  ⏵⏵ accept edits on (shift+tab to cycle)
+`, false},
+		{"other provider", "codex", `
+› 
+  Context left until auto-compact: 8%
+`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := detectFrame(t, tt.provider, tt.frame)
+			if p.ContextLow != tt.low {
+				t.Errorf("context low = %v, want %v", p.ContextLow, tt.low)
+			}
+			if p.HeuristicAttention || p.HeuristicBusy {
+				t.Errorf("advisory warning changed the status: attention %v, busy %v", p.HeuristicAttention, p.HeuristicBusy)
+			}
+		})
+	}
+}
+
+func TestDetectBusyWindow(t *testing.T) {
+	provider.RegisterCustom(provider.Spec{Name: "crush", BusyIndicators: []string{"ctrl+c to stop"}, BusyWindow: 2})
+	t.Cleanup(func() { provider.Unregister("crush") })
+	tests := []struct {
+		name, frame     string
+		busy, attention bool
+	}{
+		{"spinner on the last line", `
+  Reading internal/agent/tmux.go
+
+⠙ Thinking… (ctrl+c to stop)
+`, true, false},
+		{"spinner above a trailing blank line", `
+  Reading internal/agent/tmux.go
+⠙ Thinking… (ctrl+c to stop)
+  esc to cancel
+
+`, true, false},
+		{"stale spinner higher up", `
+⠙ Thinking… (ctrl+c to stop)
+  Reading internal/agent/tmux.go
+  Editing internal/agent/detect.go
+  Done.
+> 
+`, false, false},
+		{"prompt above the busy window", `
+Do you want to allow this command?
+  go test ./...
+  1. Yes
+  2. No
+> 
+`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := detectFrame(t, "crush", tt.frame)
+			if p.HeuristicBusy != tt.busy || p.HeuristicAttention != tt.attention {
+				t.Errorf("busy %v, attention %v; want %v, %v", p.HeuristicBusy, p.HeuristicAttention, tt.busy, tt.attention)
+			}
+		})
+	}
+}
+
+func TestDetectOpencodePrompts(t *testing.T) {
+	tests := []struct {
+		name, frame string
+		attention   bool
+	}{
+		{"permission prompt", `
+  ┃  Permission required
+  ┃  $ rm -rf node_modules && npm install
+  ┃
+  ┃  Allow once   Allow always   Reject
+  ┃
+  ┃  enter confirm  ←/→ select  esc reject
+`, true},
+		{"edit permission", `
+  ┃  Permission required to edit src/server.ts
+  ┃
+  ┃  enter accept  a accept always  d deny
+`, true},
+		{"working", `
+  ┃  Build  claude-sonnet-4
+  ┃  ⠋ Writing command...
+  ┃
+  ┃  esc interrupt                                ctrl+p commands
+`, false},
+		{"idle at the prompt", `
+  Updated the handler and added a retry test. Want me to run the suite?
+
+  ┃
+  ┃  Build  claude-sonnet-4
+  ┃
+  ┃  enter send                                   ctrl+p commands
+`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := detectFrame(t, "opencode", tt.frame)
+			if p.HeuristicAttention != tt.attention {
+				t.Errorf("attention = %v, want %v", p.HeuristicAttention, tt.attention)
+			}
+		})
+	}
+}
+
+func TestDetectIgnoresInputLine(t *testing.T) {
+	tests := []struct {
+		name, provider, frame string
+		attention             bool
+	}{
+		{"claude: typed question", "claude", `
+⏺ Added the retry.
+
+❯ Would you like me to add tests too? I think we should
+`, false},
+		{"claude: typed in the boxed composer", "claude", `
+⏺ Added the retry.
+
+╭──────────────────────────────────────────────╮
+│ > Should I proceed with the migration?       │
+╰──────────────────────────────────────────────╯
+`, false},
+		{"claude: the same question from the agent", "claude", `
+⏺ Added the retry. Would you like me to add tests too?
+
+❯ 
+`, true},
+		{"codex: typed prompt phrase", "codex", `
+• Updated the handler.
+
+› Do you want to allow network access for the tests?
+`, false},
+		{"codex: selection from the agent", "codex", `
+  Select a model
+  > gpt-5-codex
+    gpt-5
+  Press Enter to select
+› 
+`, true},
+		{"gemini: typed prompt phrase", "gemini", `
+✦ Updated the handler.
+
+╭──────────────────────────────────────────────╮
+│ > Type something about Esc to cancel          │
+╰──────────────────────────────────────────────╯
+`, false},
+		{"kimi: no markers, so nothing is excluded", "kimi", `
+Done.
+
+> Do you want to proceed?
+`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := detectFrame(t, tt.provider, tt.frame); p.HeuristicAttention != tt.attention {
+				t.Errorf("attention = %v, want %v", p.HeuristicAttention, tt.attention)
+			}
+		})
+	}
+}
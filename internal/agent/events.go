@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// Event types emitted by WatchEvents.
+const (
+	EventSnapshot = "snapshot" // every pane, sent once on start
+	EventAdded    = "added"    // a new agent pane appeared
+	EventRemoved  = "removed"  // an agent pane went away
+	EventStatus   = "status"   // a pane's status changed
+)
+
+// Event is one line of the `watch --json` stream.
+type Event struct {
+	Type  string      `json:"type"`
+	Time  time.Time   `json:"time"`
+	Pane  *EventPane  `json:"pane,omitempty"`  // added, removed, status
+	Panes []EventPane `json:"panes,omitempty"` // snapshot
+	From  string      `json:"from,omitempty"`  // status: previous status
+}
+
+// EventPane is the subset of Pane exposed to event consumers.
+type EventPane struct {
+	PaneID   string `json:"paneID"`
+	Target   string `json:"target"`
+	Path     string `json:"path"`
+	Provider string `json:"provider,omitempty"`
+	Status   string `json:"status"`
+	// SessionID is the agent's session/conversation id, when known.
+	SessionID string `json:"sessionID,omitempty"`
+}
+
+func eventPane(p Pane) EventPane {
+	return EventPane{
+		PaneID:    p.PaneID,
+		Target:    p.Target,
+		Path:      p.Path,
+		Provider:  p.Provider,
+		Status:    p.Status.String(),
+		SessionID: p.SessionID,
+	}
+}
+
+// DiffPanes returns the events that turn prev into next: removals (by pane
+// id), then additions and status changes in next's order.
+func DiffPanes(prev, next []EventPane, now time.Time) []Event {
+	before := make(map[string]EventPane, len(prev))
+	for _, p := range prev {
+		before[p.PaneID] = p
+	}
+	after := make(map[string]bool, len(next))
+	for _, p := range next {
+		after[p.PaneID] = true
+	}
+
+	var events []Event
+	var removed []EventPane
+	for _, p := range prev {
+		if !after[p.PaneID] {
+			removed = append(removed, p)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].PaneID < removed[j].PaneID })
+	for _, p := range removed {
+		events = append(events, Event{Type: EventRemoved, Time: now, Pane: &p})
+	}
+	for _, p := range next {
+		old, ok := before[p.PaneID]
+		switch {
+		case !ok:
+			events = append(events, Event{Type: EventAdded, Time: now, Pane: &p})
+		case old.Status != p.Status:
+			events = append(events, Event{Type: EventStatus, Time: now, Pane: &p, From: old.Status})
+		}
+	}
+	return events
+}
+
+// WatchEvents polls panes like Watch and writes a newline-delimited JSON
+// event stream to w: a snapshot first, then one event per change. Unlike
+// Watch it never writes the state file, so it can run alongside the daemon.
+func WatchEvents(ctx context.Context, w io.Writer) error {
+	r := NewReconciler()
+	if state, ok := LoadState(); ok {
+		r.SeedFromState(state)
+	}
+	enc := json.NewEncoder(w)
+
+	const interval = 500 * time.Millisecond
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev []EventPane
+	started := false
+	for {
+		state, _ := LoadState()
+		r.MergeOverrides(state)
+		if panes, err := ListPanes(); err == nil {
+			r.Reconcile(panes)
+			next := make([]EventPane, len(panes))
+			for i, p := range panes {
+				next[i] = eventPane(p)
+			}
+
+			now := time.Now()
+			events := DiffPanes(prev, next, now)
+			if !started {
+				events = []Event{{Type: EventSnapshot, Time: now, Panes: next}}
+				started = true
+			}
+			for _, e := range events {
+				if err := enc.Encode(e); err != nil {
+					return err
+				}
+			}
+			prev = next
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
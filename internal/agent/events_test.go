@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiffPanes(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	pane := func(id, status string) EventPane {
+		return EventPane{PaneID: id, Target: "main:" + id[1:] + ".0", Path: "/src/app", Provider: "claude", Status: status}
+	}
+	// describe flattens events to "type pane [from->to]" for comparison.
+	describe := func(events []Event) []string {
+		var out []string
+		for _, e := range events {
+			s := e.Type + " " + e.Pane.PaneID
+			if e.Type == EventStatus {
+				s += " " + e.From + "->" + e.Pane.Status
+			}
+			if !e.Time.Equal(now) {
+				s += " (wrong time)"
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		prev, next []EventPane
+		want       []string
+	}{
+		{"no change", []EventPane{pane("%1", "idle")}, []EventPane{pane("%1", "idle")}, nil},
+		{"from nothing", nil, []EventPane{pane("%1", "idle"), pane("%2", "busy")},
+			[]string{"added %1", "added %2"}},
+		{"to nothing", []EventPane{pane("%2", "busy"), pane("%1", "idle")}, nil,
+			[]string{"removed %1", "removed %2"}},
+		{"status change", []EventPane{pane("%1", "busy"), pane("%2", "idle")}, []EventPane{pane("%1", "attention"), pane("%2", "idle")},
+			[]string{"status %1 busy->attention"}},
+		{"removals first, then next's order", []EventPane{pane("%3", "idle"), pane("%1", "busy")}, []EventPane{pane("%4", "idle"), pane("%1", "idle")},
+			[]string{"removed %3", "added %4", "status %1 busy->idle"}},
+		{"other fields changing are not events", []EventPane{pane("%1", "idle")}, []EventPane{{PaneID: "%1", Target: "work:9.1", Path: "/elsewhere", Status: "idle"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describe(DiffPanes(tt.prev, tt.next, now)); !slices.Equal(got, tt.want) {
+				t.Errorf("DiffPanes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffPanesDistinctPanes(t *testing.T) {
+	next := []EventPane{{PaneID: "%1", Status: "idle"}, {PaneID: "%2", Status: "busy"}}
+	events := DiffPanes(nil, next, time.Now())
+	if len(events) != 2 || events[0].Pane == events[1].Pane {
+		t.Fatalf("events share a pane: %+v", events)
+	}
+	next[0].Status = "attention"
+	if events[0].Pane.Status != "idle" {
+		t.Error("event pane aliases the caller's slice")
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := EventPane{PaneID: "%1", Target: "main:1.0", Path: "/src/app", Status: "attention"}
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{Type: EventStatus, Time: now, Pane: &p, From: "busy"},
+			`{"type":"status","time":"2026-01-02T03:04:05Z","pane":{"paneID":"%1","target":"main:1.0","path":"/src/app","status":"attention"},"from":"busy"}`},
+		{Event{Type: EventSnapshot, Time: now, Panes: []EventPane{p}},
+			`{"type":"snapshot","time":"2026-01-02T03:04:05Z","panes":[{"paneID":"%1","target":"main:1.0","path":"/src/app","status":"attention"}]}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.event.Type, got, tt.want)
+		}
+	}
+}
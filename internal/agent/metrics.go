@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+// metricsStatuses are the statuses every provider reports a count for, so a
+// series drops to zero instead of disappearing.
+var metricsStatuses = []PaneStatus{StatusIdle, StatusBusy, StatusNeedsAttention, StatusUnread, StatusNeedsAuth}
+
+// Metrics holds the latest pane listing and serves it at /metrics in the
+// Prometheus text format:
+//
+//	agent_mux_agents{provider, status}        agents by provider and status
+//	agent_mux_refresh_timestamp_seconds       when the listing was taken
+//	agent_mux_refresh_errors_total            failed listings since start
+type Metrics struct {
+	mu      sync.Mutex
+	panes   []Pane
+	updated time.Time
+	errors  int
+}
+
+// Update records a listing, or a failure to take one.
+func (m *Metrics) Update(panes []Pane, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.errors++
+		return
+	}
+	m.panes = panes
+	m.updated = time.Now()
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	panes, updated, errs := m.panes, m.updated, m.errors
+	m.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	WriteMetrics(w, panes, updated, errs)
+}
+
+// WriteMetrics writes the metrics for panes, listed at updated, to w.
+func WriteMetrics(w io.Writer, panes []Pane, updated time.Time, errs int) {
+	counts := make(map[string]map[PaneStatus]int)
+	providers := provider.All()
+	for _, p := range panes {
+		if counts[p.Provider] == nil {
+			counts[p.Provider] = make(map[PaneStatus]int)
+		}
+		counts[p.Provider][p.Status]++
+		if !slices.Contains(providers, p.Provider) {
+			providers = append(providers, p.Provider)
+		}
+	}
+	slices.Sort(providers)
+
+	fmt.Fprintln(w, "# HELP agent_mux_agents Agent panes by provider and status.")
+	fmt.Fprintln(w, "# TYPE agent_mux_agents gauge")
+	for _, name := range providers {
+		for _, s := range metricsStatuses {
+			fmt.Fprintf(w, "agent_mux_agents{provider=%q,status=%q} %d\n",
+				name, strings.ReplaceAll(s.String(), " ", "_"), counts[name][s])
+		}
+	}
+	fmt.Fprintln(w, "# HELP agent_mux_refresh_timestamp_seconds When the agent panes were last listed.")
+	fmt.Fprintln(w, "# TYPE agent_mux_refresh_timestamp_seconds gauge")
+	var ts float64
+	if !updated.IsZero() {
+		ts = float64(updated.UnixMilli()) / 1000
+	}
+	fmt.Fprintf(w, "agent_mux_refresh_timestamp_seconds %.3f\n", ts)
+	fmt.Fprintln(w, "# HELP agent_mux_refresh_errors_total Failed pane listings since start.")
+	fmt.Fprintln(w, "# TYPE agent_mux_refresh_errors_total counter")
+	fmt.Fprintf(w, "agent_mux_refresh_errors_total %d\n", errs)
+}
+
+// ServeMetrics serves /metrics on ln, listing panes every 2s until ctx is
+// done. Like WatchEvents it never writes the state file, so it can run next
+// to the TUI and the watch daemon.
+func ServeMetrics(ctx context.Context, ln net.Listener) error {
+	m := &Metrics{}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	srv := &http.Server{Handler: mux}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+
+	r := NewReconciler()
+	if state, ok := LoadState(); ok {
+		r.SeedFromState(state)
+	}
+	const interval = 2 * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		state, _ := LoadState()
+		r.MergeOverrides(state)
+		panes, err := ListPanes()
+		if err == nil {
+			r.Reconcile(panes)
+		}
+		m.Update(panes, err)
+
+		select {
+		case <-ctx.Done():
+			shutdown, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdown)
+		case err := <-serveErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return fmt.Errorf("metrics: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	m := &Metrics{}
+	m.Update(nil, errors.New("tmux list-panes: no server running"))
+	m.Update([]Pane{
+		{PaneID: "%1", Provider: "claude", Status: StatusNeedsAttention},
+		{PaneID: "%2", Provider: "claude", Status: StatusNeedsAttention},
+		{PaneID: "%3", Provider: "claude", Status: StatusBusy},
+		{PaneID: "%4", Provider: "codex", Status: StatusIdle},
+	}, nil)
+	m.updated = time.UnixMilli(1767366245123)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	got := string(body)
+
+	for _, want := range []string{
+		"# TYPE agent_mux_agents gauge\n",
+		`agent_mux_agents{provider="claude",status="needs_attention"} 2` + "\n",
+		`agent_mux_agents{provider="claude",status="busy"} 1` + "\n",
+		`agent_mux_agents{provider="claude",status="idle"} 0` + "\n",
+		`agent_mux_agents{provider="codex",status="idle"} 1` + "\n",
+		`agent_mux_agents{provider="codex",status="needs_login"} 0` + "\n",
+		"agent_mux_refresh_timestamp_seconds 1767366245.123\n",
+		"# TYPE agent_mux_refresh_errors_total counter\n",
+		"agent_mux_refresh_errors_total 1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+
+	// Every registered provider reports every status, even with no panes.
+	for _, name := range provider.All() {
+		for _, s := range metricsStatuses {
+			series := `agent_mux_agents{provider="` + name + `",status="` + strings.ReplaceAll(s.String(), " ", "_") + `"} `
+			if !strings.Contains(got, series) {
+				t.Errorf("metrics missing series %s", series)
+			}
+		}
+	}
+}
+
+func TestWriteMetricsBeforeFirstListing(t *testing.T) {
+	var b strings.Builder
+	WriteMetrics(&b, nil, time.Time{}, 0)
+	if !strings.Contains(b.String(), "agent_mux_refresh_timestamp_seconds 0.000\n") {
+		t.Errorf("timestamp before the first listing should be 0:\n%s", b.String())
+	}
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// NoteKey returns the identity a pane's note is stored under. It combines the
+// working directory with session:window so notes survive pane renumbering
+// within a window and agent-mux restarts.
+func NoteKey(p *Pane) string {
+	return p.Path + " " + p.Session + ":" + p.Window
+}
+
+// LoadNotes reads the user's pane notes. Missing or unreadable files yield an
+// empty map.
+func LoadNotes() map[string]string {
+	notes := make(map[string]string)
+	data, err := os.ReadFile(stateFile("notes.json"))
+	if err != nil {
+		return notes
+	}
+	_ = json.Unmarshal(data, &notes)
+	return notes
+}
+
+// SaveNotes writes notes atomically.
+func SaveNotes(notes map[string]string) error {
+	path := stateFile("notes.json")
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// Notification events, matched against the "event" of notify rules.
+const (
+	NotifyDone      = "done"      // an agent finished working
+	NotifyAttention = "attention" // an agent needs attention (e.g. a tool approval)
+	NotifyAuth      = "auth"      // an agent is waiting for login
+)
+
+// notifyEvent maps a status transition to the notification event it raises,
+// or "" for transitions nobody is notified about.
+func notifyEvent(e Event) string {
+	if e.Type != EventStatus {
+		return ""
+	}
+	switch e.Pane.Status {
+	case StatusNeedsAttention.String():
+		return NotifyAttention
+	case StatusNeedsAuth.String():
+		return NotifyAuth
+	case StatusIdle.String(), StatusUnread.String():
+		if e.From == StatusBusy.String() {
+			return NotifyDone
+		}
+	}
+	return ""
+}
+
+// notifyRule returns the rule for a provider and event: one naming the
+// provider wins over a catch-all one without a provider.
+func notifyRule(rules []config.NotifyRule, providerName, event string) (config.NotifyRule, bool) {
+	var fallback *config.NotifyRule
+	for i, r := range rules {
+		if r.Event != event {
+			continue
+		}
+		if r.Provider == providerName {
+			return r, true
+		}
+		if r.Provider == "" && fallback == nil {
+			fallback = &rules[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return config.NotifyRule{}, false
+}
+
+// notify runs the configured command for every notifying transition in
+// events. Commands run through sh in the background with the pane described
+// by AGENTMUX_EVENT, AGENTMUX_PROVIDER, AGENTMUX_TARGET and AGENTMUX_PATH.
+func notify(events []Event) {
+	rules := config.Get().Notify
+	if len(rules) == 0 {
+		return
+	}
+	for _, e := range events {
+		event := notifyEvent(e)
+		if event == "" {
+			continue
+		}
+		rule, ok := notifyRule(rules, e.Pane.Provider, event)
+		if !ok || rule.Command == "" {
+			continue
+		}
+		cmd := exec.Command("sh", "-c", rule.Command)
+		cmd.Env = append(os.Environ(),
+			"AGENTMUX_EVENT="+event,
+			"AGENTMUX_PROVIDER="+e.Pane.Provider,
+			"AGENTMUX_TARGET="+e.Pane.Target,
+			"AGENTMUX_PATH="+e.Pane.Path,
+		)
+		if err := cmd.Start(); err != nil {
+			debugf("notify %s %s: %v", event, e.Pane.Target, err)
+			continue
+		}
+		go cmd.Wait()
+	}
+}
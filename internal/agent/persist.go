@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 )
@@ -30,9 +31,61 @@ type CachedPane struct {
 
 type State struct {
 	Version      int          `json:"version"`
+	SavedAt      time.Time    `json:"savedAt,omitzero"`
 	Panes        []CachedPane `json:"panes"`
 	LastPosition LastPosition `json:"lastPosition"`
 	SidebarWidth int          `json:"sidebarWidth,omitempty"`
+	// WorkspaceOrder lists workspace paths (project roots for worktree
+	// projects) in the order the user arranged them with K/J.
+	WorkspaceOrder []string `json:"workspaceOrder,omitempty"`
+	// Recent remembers where each provider ran, most recent first, so
+	// agents can be restarted there after they exit.
+	Recent []RecentProject `json:"recent,omitempty"`
+}
+
+// RecentProject is a directory an agent ran in.
+type RecentProject struct {
+	Path       string    `json:"path"`
+	ShortPath  string    `json:"shortPath,omitempty"`
+	Provider   string    `json:"provider"`
+	LastActive time.Time `json:"lastActive,omitzero"`
+}
+
+// maxRecent caps State.Recent.
+const maxRecent = 50
+
+// RememberRecent merges panes into recent, one entry per directory and
+// provider, and returns it ordered by last activity, newest first.
+func RememberRecent(recent []RecentProject, panes []*Pane) []RecentProject {
+	type key struct{ path, provider string }
+	index := make(map[key]int, len(recent))
+	for i, r := range recent {
+		index[key{r.Path, r.Provider}] = i
+	}
+	for _, p := range panes {
+		if p.Provider == "" || p.Path == "" {
+			continue
+		}
+		last := p.LastActive
+		if last.IsZero() {
+			last = time.Now()
+		}
+		k := key{p.Path, p.Provider}
+		if i, ok := index[k]; ok {
+			recent[i].ShortPath = p.ShortPath
+			if last.After(recent[i].LastActive) {
+				recent[i].LastActive = last
+			}
+			continue
+		}
+		index[k] = len(recent)
+		recent = append(recent, RecentProject{Path: p.Path, ShortPath: p.ShortPath, Provider: p.Provider, LastActive: last})
+	}
+	slices.SortStableFunc(recent, func(a, b RecentProject) int { return b.LastActive.Compare(a.LastActive) })
+	if len(recent) > maxRecent {
+		recent = recent[:maxRecent]
+	}
+	return recent
 }
 
 type LastPosition struct {
@@ -53,11 +106,17 @@ func (cp CachedPane) paneKey() string {
 
 var stateDir sync.Once
 
-func statePath() string {
+// stateFile returns the path of name inside the agent-mux state directory,
+// creating the directory on first use.
+func stateFile(name string) string {
 	home, _ := os.UserHomeDir()
 	dir := filepath.Join(home, ".local", "state", "agent-mux")
 	stateDir.Do(func() { os.MkdirAll(dir, 0755) })
-	return filepath.Join(dir, "state.json")
+	return filepath.Join(dir, name)
+}
+
+func statePath() string {
+	return stateFile("state.json")
 }
 
 func LoadState() (State, bool) {
@@ -81,6 +140,7 @@ func LoadState() (State, bool) {
 func SaveState(state State) error {
 	path := statePath()
 	state.Version = 1
+	state.SavedAt = time.Now()
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return err
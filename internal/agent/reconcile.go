@@ -1,6 +1,10 @@
 package agent
 
-import "time"
+import (
+	"time"
+
+	"github.com/leo/agent-mux/internal/config"
+)
 
 // StatusOverride captures a user-toggled status. Unread overrides persist
 // through content changes (manual bookmark); others clear on new output.
@@ -11,10 +15,11 @@ type StatusOverride struct {
 
 // Reconciler tracks per-pane activity and drives the status state machine:
 //
-//	Idle → Busy (content changed)
+//	Idle → Busy (content changed, or provider busy indicator shown)
 //	Busy → Idle (content settled + user viewing window)
 //	Busy → NeedsAttention (content settled + user not viewing, or heuristic match)
 //	* → NeedsAttention (heuristic match, when not busy)
+//	* → NeedsAuth (login / API key prompt on screen)
 //
 // All maps are keyed by PaneID (tmux's stable pane identifier).
 // Both the TUI and the background watch daemon use this.
@@ -24,26 +29,41 @@ type Reconciler struct {
 	prevStatuses   map[string]PaneStatus
 	overrides      map[string]StatusOverride
 	lastActive     map[string]time.Time
+
+	// attentionTicks counts consecutive ticks with the attention heuristic
+	// matching; it must reach attentionDebounce before the match counts, so
+	// a prompt drawn for a single frame doesn't flap the status.
+	attentionTicks    map[string]int
+	attentionDebounce int
 }
 
 func NewReconciler() *Reconciler {
 	return &Reconciler{
-		prevContent:    make(map[string]string),
-		unchangedCount: make(map[string]int),
-		prevStatuses:   make(map[string]PaneStatus),
-		overrides:      make(map[string]StatusOverride),
-		lastActive:     make(map[string]time.Time),
+		prevContent:       make(map[string]string),
+		unchangedCount:    make(map[string]int),
+		prevStatuses:      make(map[string]PaneStatus),
+		overrides:         make(map[string]StatusOverride),
+		lastActive:        make(map[string]time.Time),
+		attentionTicks:    make(map[string]int),
+		attentionDebounce: max(config.Get().AttentionDebounceTicks, 1),
 	}
 }
 
-// SeedFromState restores tracking state from a persisted State.
+// staleStatusAge bounds how old a saved state may be for its last-known
+// statuses to be trusted on startup. Older statuses (e.g. a "busy" from before
+// a reboot) are dropped and re-detected from scratch.
+const staleStatusAge = 10 * time.Minute
+
+// SeedFromState restores tracking state from a persisted State, so the first
+// frame shows the statuses from the last save while detection catches up.
 func (r *Reconciler) SeedFromState(state State) {
+	fresh := time.Since(state.SavedAt) < staleStatusAge
 	for _, cp := range state.Panes {
 		id := cp.paneKey()
 		if cp.ContentHash != "" {
 			r.prevContent[id] = cp.ContentHash
 		}
-		if cp.LastStatus != nil {
+		if cp.LastStatus != nil && fresh {
 			r.prevStatuses[id] = PaneStatus(*cp.LastStatus)
 		}
 		if cp.StatusOverride != nil {
@@ -88,10 +108,11 @@ func (r *Reconciler) ClearPane(paneID string) {
 	delete(r.prevStatuses, paneID)
 	delete(r.prevContent, paneID)
 	delete(r.unchangedCount, paneID)
+	delete(r.attentionTicks, paneID)
 }
 
 // Reconcile runs the status state machine on a fresh set of panes.
-// Pane statuses are updated in place.
+// Pane statuses, and the labels describing them, are updated in place.
 func (r *Reconciler) Reconcile(panes []Pane) {
 	now := time.Now()
 	alive := make(map[string]bool, len(panes))
@@ -102,6 +123,13 @@ func (r *Reconciler) Reconcile(panes []Pane) {
 
 		contentChanged := p.ContentHash != "" && p.ContentHash != r.prevContent[id]
 
+		if p.HeuristicAttention {
+			r.attentionTicks[id]++
+		} else {
+			delete(r.attentionTicks, id)
+		}
+		attention := p.HeuristicAttention && r.attentionTicks[id] >= r.attentionDebounce
+
 		// Track per-pane activity: update on content change, apply if tracked.
 		if contentChanged {
 			r.lastActive[id] = now
@@ -110,6 +138,13 @@ func (r *Reconciler) Reconcile(panes []Pane) {
 			p.LastActive = t
 		}
 
+		// Copy mode freezes what capture-pane sees; hold the last status
+		// until the user leaves the mode.
+		if p.InMode {
+			p.Status = r.prevStatuses[id]
+			continue
+		}
+
 		if ov, ok := r.overrides[id]; ok {
 			if contentChanged {
 				delete(r.overrides, id)
@@ -121,7 +156,15 @@ func (r *Reconciler) Reconcile(panes []Pane) {
 			}
 		}
 
-		if contentChanged {
+		if p.HeuristicAuth {
+			p.Status = StatusNeedsAuth
+			r.unchangedCount[id] = 0
+		} else if p.HeuristicBusy {
+			// The provider says it's working regardless of who is watching;
+			// settling starts once the indicator disappears.
+			p.Status = StatusBusy
+			r.unchangedCount[id] = 0
+		} else if contentChanged {
 			if !p.WindowActive {
 				p.Status = StatusBusy
 			} else {
@@ -133,7 +176,7 @@ func (r *Reconciler) Reconcile(panes []Pane) {
 		} else if r.prevStatuses[id] == StatusBusy {
 			r.unchangedCount[id]++
 			if r.unchangedCount[id] >= 2 {
-				if p.HeuristicAttention {
+				if attention {
 					p.Status = StatusNeedsAttention
 				} else if p.WindowActive {
 					p.Status = StatusIdle
@@ -143,7 +186,7 @@ func (r *Reconciler) Reconcile(panes []Pane) {
 			} else {
 				p.Status = StatusBusy
 			}
-		} else if p.HeuristicAttention {
+		} else if attention {
 			p.Status = StatusNeedsAttention
 		} else if r.prevStatuses[id] == StatusNeedsAttention {
 			p.Status = StatusNeedsAttention
@@ -160,6 +203,9 @@ func (r *Reconciler) Reconcile(panes []Pane) {
 		}
 		r.prevStatuses[id] = p.Status
 	}
+	for i := range panes {
+		labelStatus(&panes[i])
+	}
 	r.cleanup(alive)
 }
 
@@ -248,4 +294,9 @@ func (r *Reconciler) cleanup(alive map[string]bool) {
 			delete(r.overrides, id)
 		}
 	}
+	for id := range r.attentionTicks {
+		if !alive[id] {
+			delete(r.attentionTicks, id)
+		}
+	}
 }
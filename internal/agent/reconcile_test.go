@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/leo/agent-mux/internal/config"
+)
+
+func TestReconcileLabelsByStatus(t *testing.T) {
+	r := NewReconciler()
+	frame := []byte("⏺ Bash(go test ./...)\n✻ Running… (12s · esc to interrupt)")
+	p := Pane{PaneID: "%1", Provider: "claude", WindowActive: true}
+	Detect(&p, frame)
+	panes := []Pane{p}
+	r.Reconcile(panes)
+	if panes[0].Status != StatusBusy || panes[0].StatusLabel != "generating" {
+		t.Errorf("busy pane: status %v, label %q; want busy, generating", panes[0].Status, panes[0].StatusLabel)
+	}
+
+	// Marked read by the user, the stale spinner line no longer labels it.
+	r.SetOverride("%1", StatusIdle, p.ContentHash)
+	panes = []Pane{p}
+	panes[0].HeuristicBusy = false
+	r.Reconcile(panes)
+	if panes[0].Status != StatusIdle || panes[0].StatusLabel != "" {
+		t.Errorf("idle pane: status %v, label %q; want idle and no label", panes[0].Status, panes[0].StatusLabel)
+	}
+}
+
+func TestReconcileAttentionDebounce(t *testing.T) {
+	tests := []struct {
+		name     string
+		debounce int
+		ticks    []bool // the attention heuristic on each tick
+		want     []PaneStatus
+	}{
+		{"default reacts at once", 0,
+			[]bool{false, true},
+			[]PaneStatus{StatusIdle, StatusNeedsAttention}},
+		{"held for the debounce", 3,
+			[]bool{true, true, true},
+			[]PaneStatus{StatusIdle, StatusIdle, StatusNeedsAttention}},
+		{"flicker never counts", 3,
+			[]bool{true, false, true, true, false, true},
+			[]PaneStatus{StatusIdle, StatusIdle, StatusIdle, StatusIdle, StatusIdle, StatusIdle}},
+		{"a miss restarts the count", 2,
+			[]bool{true, false, true, true},
+			[]PaneStatus{StatusIdle, StatusIdle, StatusIdle, StatusNeedsAttention}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, config.Config{AttentionDebounceTicks: tt.debounce})
+			r := NewReconciler()
+			var got []PaneStatus
+			for _, attention := range tt.ticks {
+				panes := []Pane{{PaneID: "%1", ContentHash: "same", WindowActive: true, HeuristicAttention: attention}}
+				r.Reconcile(panes)
+				got = append(got, panes[0].Status)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("statuses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+// resolveCache remembers, per pane process, the child process found to be
+// the agent. Agents hosted by a runtime (gemini as node) otherwise cost a
+// walk over the pane's children and their arguments on every tick.
+var resolveCache = struct {
+	sync.Mutex
+	byPID map[int]resolvedAgent
+}{byPID: make(map[int]resolvedAgent)}
+
+type resolvedAgent struct {
+	name string
+	pid  int
+}
+
+// ResolveProvider is provider.ResolvePID with a cache for agents found among
+// the pane process's children: the answer is reused for as long as that
+// child is still running under it.
+func ResolveProvider(cmd string, panePID int, pt *provider.ProcessTable) (string, int) {
+	if provider.IsAgent(cmd) || len(pt.Comm) == 0 {
+		return provider.ResolvePID(cmd, panePID, pt)
+	}
+	resolveCache.Lock()
+	c, ok := resolveCache.byPID[panePID]
+	resolveCache.Unlock()
+	if ok && slices.Contains(pt.Children[panePID], c.pid) {
+		return c.name, c.pid
+	}
+	name, pid := provider.ResolvePID(cmd, panePID, pt)
+	if name != "" && pid != panePID {
+		resolveCache.Lock()
+		resolveCache.byPID[panePID] = resolvedAgent{name: name, pid: pid}
+		resolveCache.Unlock()
+	}
+	return name, pid
+}
+
+// forgetResolved drops cached agents of pane processes no longer in pt.
+func forgetResolved(pt *provider.ProcessTable) {
+	if len(pt.Comm) == 0 {
+		return
+	}
+	resolveCache.Lock()
+	defer resolveCache.Unlock()
+	for pid := range resolveCache.byPID {
+		if _, ok := pt.Comm[pid]; !ok {
+			delete(resolveCache.byPID, pid)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+func TestResolveProviderCachesAgentChild(t *testing.T) {
+	t.Cleanup(func() {
+		pt := provider.ParseProcessTable("1 0 init")
+		forgetResolved(&pt)
+	})
+	pt := provider.ParseProcessTable(`
+  100     1 -zsh
+  101   100 node /opt/app/worker.js
+  102   100 node /usr/local/lib/node_modules/@google/gemini-cli/dist/index.js
+`)
+	if name, pid := ResolveProvider("zsh", 100, &pt); name != "gemini" || pid != 102 {
+		t.Fatalf("ResolveProvider = %q, %d; want gemini, 102", name, pid)
+	}
+	// A later listing where the child's args no longer match still names the
+	// cached agent: the same process is running under the pane.
+	pt.Args[102] = "node"
+	if name, pid := ResolveProvider("zsh", 100, &pt); name != "gemini" || pid != 102 {
+		t.Errorf("cached ResolveProvider = %q, %d; want gemini, 102", name, pid)
+	}
+
+	// Once the child exits, the pane is resolved again.
+	pt = provider.ParseProcessTable("100 1 -zsh\n101 100 node /opt/app/worker.js\n")
+	forgetResolved(&pt)
+	if name, _ := ResolveProvider("zsh", 100, &pt); name != "" {
+		t.Errorf("ResolveProvider after the agent exited = %q, want none", name)
+	}
+}
+
+// BenchmarkResolveProvider resolves 200 panes, each a shell running gemini
+// under node next to a few other node children, one tick per iteration:
+// walking the process tree every time, and through the cache.
+func BenchmarkResolveProvider(b *testing.B) {
+	var sb strings.Builder
+	pid := 100000
+	panePIDs := make([]int, 200)
+	for i := range panePIDs {
+		panePIDs[i] = pid
+		fmt.Fprintf(&sb, "%6d %6d -zsh\n", pid, 1)
+		for j := range 5 {
+			fmt.Fprintf(&sb, "%6d %6d /usr/local/bin/node /opt/app/node_modules/.bin/worker --id %d\n", pid+1+j, pid, j)
+		}
+		fmt.Fprintf(&sb, "%6d %6d /usr/local/bin/node /usr/local/lib/node_modules/@google/gemini-cli/dist/index.js\n", pid+6, pid)
+		pid += 10
+	}
+	pt := provider.ParseProcessTable(sb.String())
+
+	b.Run("walk", func(b *testing.B) {
+		for b.Loop() {
+			for _, p := range panePIDs {
+				provider.ResolvePID("zsh", p, &pt)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for b.Loop() {
+			for _, p := range panePIDs {
+				ResolveProvider("zsh", p, &pt)
+			}
+		}
+	})
+}
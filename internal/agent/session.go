@@ -1,12 +1,16 @@
 package agent
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/leo/agent-mux/internal/config"
 )
 
 // PaneStatus represents the state of an agent pane.
@@ -17,8 +21,30 @@ const (
 	StatusBusy                             // agent is working
 	StatusNeedsAttention                   // heuristic-detected attention
 	StatusUnread                           // finished but not viewed, or manually bookmarked
+	StatusNeedsAuth                        // agent is waiting for login / an API key
 )
 
+// WantsAttention reports whether the status asks the user to look at the pane.
+func (s PaneStatus) WantsAttention() bool {
+	return s == StatusNeedsAttention || s == StatusUnread || s == StatusNeedsAuth
+}
+
+// String returns a short human-readable name for the status.
+func (s PaneStatus) String() string {
+	switch s {
+	case StatusBusy:
+		return "busy"
+	case StatusNeedsAttention:
+		return "needs attention"
+	case StatusUnread:
+		return "unread"
+	case StatusNeedsAuth:
+		return "needs login"
+	default:
+		return "idle"
+	}
+}
+
 // Pane represents a tmux pane running an AI coding agent.
 type Pane struct {
 	PaneID             string // stable tmux pane id, e.g. "%42"
@@ -29,21 +55,39 @@ type Pane struct {
 	Pane               string
 	Path               string
 	ShortPath          string
-	ProjectRoot        string // main repo path; equals Path when not a worktree
+	ProjectRoot        string // main repo path, symlinks resolved; equals Path when not a worktree or a second path to one
 	ProjectShort       string // basename of ProjectRoot
 	ProjectBranch      string // branch of ProjectRoot
 	ProjectDirty       bool   // dirty state of ProjectRoot
 	GitBranch          string
 	GitDirty           bool
+	PathMissing        bool // working directory no longer exists
 	PID                int
 	Status             PaneStatus
 	ContentHash        string
 	HeuristicAttention bool
+	HeuristicBusy      bool // provider's busy indicator is on screen
+	HeuristicAuth      bool // a login / API key prompt is on screen
+	HeuristicDone      bool // provider's completion summary is on screen
+	ContextLow         bool // provider warns the context is nearly full (advisory; not a status)
 	WindowActive       bool
-	LastActive         time.Time
+	LastActive         time.Time // last time captured output changed, for any provider (set by Reconciler)
 	Stashed            bool
 	Order              int    // position in tmux list-panes output
 	Provider           string // resolved agent provider name (claude, codex, kimi, etc.)
+	Model              string // model the agent was launched with, from its args (e.g. "opus")
+	Args               string // command line of the agent process
+	SessionID          string // agent's session/conversation id, when the provider can tell
+	Command            string // pane_current_command; set only for non-agent panes (ListOtherPanes)
+	Container          string // container command the agent runs under (e.g. "docker"), detected from its screen
+	StatusLabel        string // provider-specific description, e.g. "generating"
+	AttentionLine      string // captured line that made the pane need attention, whitespace collapsed
+	InMode             bool   // pane is in copy mode (or another tmux mode)
+	AltScreen          bool   // pane is showing the alternate screen (full-screen TUI)
+	BlockedBy          string // "pager" or "editor": the agent waits for the user to quit a program it opened
+	Activity           int64  // window_activity: unix second of the last output in the pane's window
+
+	lines []string // normalized capture Detect read, for labelStatus
 }
 
 // EnrichPanes populates workspace metadata (ShortPath, GitBranch, GitDirty,
@@ -52,6 +96,10 @@ type Pane struct {
 func EnrichPanes(panes []Pane) {
 	home, _ := os.UserHomeDir()
 	shorten := func(p string) string {
+		if p == "" {
+			return ""
+		}
+		p = filepath.Clean(p)
 		short := filepath.Base(p)
 		if short == "." || short == "/" {
 			short = p
@@ -68,6 +116,8 @@ func EnrichPanes(panes []Pane) {
 		ProjectShort string
 		GitBranch    string
 		GitDirty     bool
+		Missing      bool
+		Canonical    string // path with symlinks resolved
 	}
 
 	unique := make(map[string]*wsInfo)
@@ -82,15 +132,48 @@ func EnrichPanes(panes []Pane) {
 		wg.Add(1)
 		go func(path string, info *wsInfo) {
 			defer wg.Done()
-			info.GitBranch = gitBranch(path)
-			info.GitDirty = gitDirty(path)
-			root := projectRoot(path)
+			// A deleted directory keeps its last known name but has no git
+			// state to read.
+			if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+				info.Missing = true
+				info.ProjectRoot = path
+				info.ProjectShort = info.ShortPath
+				return
+			}
+			// Read git state through symlinks, so the same repository reached
+			// by two paths reports one branch and groups under one root.
+			canonical := path
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				canonical = resolved
+			}
+			info.GitBranch = gitBranch(canonical)
+			info.GitDirty = gitDirty(canonical)
+			info.Canonical = canonical
+			top := canonical
+			if config.Get().GroupBy == "repo" {
+				top = repoTop(canonical)
+			}
+			root := projectRoot(top)
 			info.ProjectRoot = root
 			info.ProjectShort = shorten(root)
 		}(path, info)
 	}
 	wg.Wait()
 
+	// A path that is merely a symlink to a repository only needs its
+	// canonical root when another pane reaches the same repository by a
+	// different path; alone, it stays a plain workspace under its own name.
+	paths := make(map[string]int)
+	for _, info := range unique {
+		paths[info.ProjectRoot]++
+	}
+	for path, info := range unique {
+		if info.Canonical != path && info.ProjectRoot == info.Canonical && paths[info.ProjectRoot] == 1 {
+			info.ProjectRoot = path
+			info.ProjectShort = info.ShortPath
+		}
+	}
+
 	// Resolve branch/dirty for each unique project root (parallel) so the
 	// project header can show the main-repo branch even when no pane lives
 	// at the root path.
@@ -122,6 +205,7 @@ func EnrichPanes(panes []Pane) {
 		panes[i].ProjectShort = info.ProjectShort
 		panes[i].GitBranch = info.GitBranch
 		panes[i].GitDirty = info.GitDirty
+		panes[i].PathMissing = info.Missing
 		if pi := projects[info.ProjectRoot]; pi != nil {
 			panes[i].ProjectBranch = pi.Branch
 			panes[i].ProjectDirty = pi.Dirty
@@ -129,6 +213,39 @@ func EnrichPanes(panes []Pane) {
 	}
 }
 
+// repoTops caches repoTop by directory; repositories don't move often enough
+// to walk up from every pane on every refresh.
+var repoTops = struct {
+	sync.Mutex
+	byDir map[string]string
+}{byDir: make(map[string]string)}
+
+// repoTop returns the top of the git working tree containing dir: the
+// nearest directory at or above it with a .git entry, or dir itself when
+// there is none.
+func repoTop(dir string) string {
+	repoTops.Lock()
+	top, ok := repoTops.byDir[dir]
+	repoTops.Unlock()
+	if ok {
+		return top
+	}
+	top = dir
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(filepath.Join(d, ".git")); err == nil {
+			top = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	repoTops.Lock()
+	repoTops.byDir[dir] = top
+	repoTops.Unlock()
+	return top
+}
+
 // projectRoot returns the main repo path for dir. If dir is a git worktree
 // (i.e. <dir>/.git is a file), the main repo is parsed from its gitdir
 // pointer. Otherwise dir itself is returned.
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// useConfig makes cfg the loaded config for the rest of the test.
+func useConfig(t *testing.T, cfg config.Config) {
+	t.Helper()
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+}
+
+// mkdirs creates each of dirs under root and returns root.
+func mkdirs(t *testing.T, root string, dirs ...string) string {
+	t.Helper()
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestRepoTop(t *testing.T) {
+	root := mkdirs(t, t.TempDir(), "mono/.git", "mono/services/api", "plain/sub")
+	// A worktree's .git is a file, and still marks the top.
+	wt := mkdirs(t, root, "wt/pkg")
+	if err := os.WriteFile(filepath.Join(wt, "wt", ".git"), []byte("gitdir: ../mono/.git/worktrees/wt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ dir, want string }{
+		{"mono", "mono"},
+		{"mono/services", "mono"},
+		{"mono/services/api", "mono"},
+		{"wt/pkg", "wt"},
+		{"plain/sub", "plain/sub"}, // no repository: the directory itself
+	}
+	for _, tt := range tests {
+		dir := filepath.Join(root, tt.dir)
+		if got, want := repoTop(dir), filepath.Join(root, tt.want); got != want {
+			t.Errorf("repoTop(%s) = %s, want %s", tt.dir, got, want)
+		}
+	}
+}
+
+func TestEnrichPanesGroupBy(t *testing.T) {
+	root, err := filepath.EvalSymlinks(mkdirs(t, t.TempDir(), "mono/.git", "mono/api", "mono/web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono := filepath.Join(root, "mono")
+	tests := []struct {
+		groupBy string
+		want    []string // ProjectRoot of each pane
+	}{
+		{"", []string{filepath.Join(mono, "api"), filepath.Join(mono, "web")}},
+		{"path", []string{filepath.Join(mono, "api"), filepath.Join(mono, "web")}},
+		{"repo", []string{mono, mono}},
+		{"session", []string{filepath.Join(mono, "api"), filepath.Join(mono, "web")}},
+	}
+	for _, tt := range tests {
+		useConfig(t, config.Config{GroupBy: tt.groupBy})
+		panes := []Pane{
+			{PaneID: "%1", Path: filepath.Join(mono, "api")},
+			{PaneID: "%2", Path: filepath.Join(mono, "web")},
+		}
+		EnrichPanes(panes)
+		for i, p := range panes {
+			if p.ProjectRoot != tt.want[i] {
+				t.Errorf("group_by %q: %s grouped under %s, want %s", tt.groupBy, p.Path, p.ProjectRoot, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestEnrichPanesSymlinkedWorkspace(t *testing.T) {
+	root, err := filepath.EvalSymlinks(mkdirs(t, t.TempDir(), "repo/.git", "other/.git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(root, "repo")
+	for dir, branch := range map[string]string{"repo": "main", "other": "dev"} {
+		if err := os.WriteFile(filepath.Join(root, dir, ".git", "HEAD"), []byte("ref: refs/heads/"+branch+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(root, "link")
+	alone := filepath.Join(root, "alone")
+	for target, name := range map[string]string{repo: link, filepath.Join(root, "other"): alone} {
+		if err := os.Symlink(target, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	useConfig(t, config.Config{})
+	panes := []Pane{
+		{PaneID: "%1", Path: repo},
+		{PaneID: "%2", Path: link},
+		{PaneID: "%3", Path: alone},
+	}
+	EnrichPanes(panes)
+	want := []struct{ root, branch string }{
+		{repo, "main"},
+		{repo, "main"}, // merged with the repository it links to
+		{alone, "dev"}, // the only path to its repository keeps its own name
+	}
+	for i, p := range panes {
+		if p.ProjectRoot != want[i].root || p.GitBranch != want[i].branch {
+			t.Errorf("%s: root %s, branch %q; want %s, %q", p.Path, p.ProjectRoot, p.GitBranch, want[i].root, want[i].branch)
+		}
+	}
+}
+
+func TestEnrichPanesShortPath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(mkdirs(t, t.TempDir(), "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	useConfig(t, config.Config{})
+	app := filepath.Join(root, "app")
+	tests := []struct{ path, short string }{
+		{app, "app"},
+		{app + "/", "app"},
+		{app + "//", "app"},
+		{app + "/./", "app"},
+		{"/", "/"},
+		{"//", "/"},
+	}
+	for _, tt := range tests {
+		panes := []Pane{{PaneID: "%1", Path: tt.path}}
+		EnrichPanes(panes)
+		if panes[0].ShortPath != tt.short {
+			t.Errorf("ShortPath(%q) = %q, want %q", tt.path, panes[0].ShortPath, tt.short)
+		}
+	}
+}
@@ -2,71 +2,343 @@ package agent
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/leo/agent-mux/internal/config"
 	"github.com/leo/agent-mux/internal/provider"
 )
 
+// commander runs external programs: every tmux and ps invocation goes through
+// cmdr, so tests can stand in a fake server.
+type commander interface {
+	// Output runs name with args and returns its stdout. A failed command's
+	// error includes what it printed on stderr.
+	Output(name string, args ...string) ([]byte, error)
+	// Sleep pauses between commands, e.g. before a retry.
+	Sleep(d time.Duration)
+}
+
+// execCommander runs commands for real.
+type execCommander struct{}
+
+func (execCommander) Output(name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return out, err
+}
+
+func (execCommander) Sleep(d time.Duration) { time.Sleep(d) }
+
+var cmdr commander = execCommander{}
+
+// tmuxSocket, when set, is passed as -S to every tmux invocation.
+var tmuxSocket string
+
+// SetSocket directs all tmux commands at the server listening on path.
+func SetSocket(path string) {
+	tmuxSocket = path
+}
+
+// tmuxExec runs tmux once and returns its stdout. The binary defaults to
+// "tmux" on PATH and can be overridden with AGENTMUX_TMUX (e.g. a specific
+// build or a wrapper).
+func tmuxExec(args ...string) ([]byte, error) {
+	if tmuxSocket != "" {
+		args = append([]string{"-S", tmuxSocket}, args...)
+	}
+	return cmdr.Output(envOr("AGENTMUX_TMUX", "tmux"), args...)
+}
+
+// tmuxRun runs tmux once for its side effect.
+func tmuxRun(args ...string) error {
+	_, err := tmuxExec(args...)
+	return err
+}
+
+// tmuxRetryDelays are the pauses before each retry of a read-only tmux query.
+// A busy server occasionally fails a call outright; retrying briefly keeps a
+// pane from vanishing (or its preview showing an error) for a whole tick.
+var tmuxRetryDelays = []time.Duration{20 * time.Millisecond, 60 * time.Millisecond}
+
+// transientErrors are what tmux prints when the server, not the request,
+// failed: it was too busy to answer or restarting.
+var transientErrors = []string{"lost server", "server exited unexpectedly", "Resource temporarily unavailable",
+	"Connection refused", "Interrupted system call"}
+
+// transientTmuxError reports whether err is worth retrying. A pane that is
+// gone or an unknown option fails the same way every time.
+func transientTmuxError(err error) bool {
+	msg := err.Error()
+	for _, t := range transientErrors {
+		if strings.Contains(msg, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// tmuxOutput runs a read-only tmux command and returns its stdout, retrying
+// transient failures. Commands with side effects go through tmuxRun directly
+// so they never run twice.
+func tmuxOutput(args ...string) ([]byte, error) {
+	out, err := tmuxExec(args...)
+	for _, d := range tmuxRetryDelays {
+		if err == nil || !transientTmuxError(err) {
+			break
+		}
+		cmdr.Sleep(d)
+		out, err = tmuxExec(args...)
+	}
+	return out, err
+}
+
+// TmuxReachable reports whether a tmux server answers, for when $TMUX is
+// unset (e.g. over SSH or in nested setups) but a server is still available.
+func TmuxReachable() bool {
+	return tmuxRun("list-sessions") == nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 // rawPane holds parsed tmux pane info before status detection.
 type rawPane struct {
 	paneID, target, session, window, windowName, pane, path, cmd string
 	pid                                                          int
 	windowFocused                                                bool
+	inMode                                                       bool
+	altScreen                                                    bool
+	activity                                                     int64
+	model, args                                                  string // set by resolveAgentPanes
+	container                                                    string // container command hosting the agent, if any
+	blockedBy                                                    string // "pager" or "editor" the agent opened and waits on
 }
 
-// parseTmuxPanes parses tmux list-panes output into rawPane structs.
+// tmuxPaneFields is the number of tab-separated fields listTmuxPanes asks for.
+const tmuxPaneFields = 10
+
+// parseTmuxPanes parses tmux list-panes output into rawPane structs. Older
+// tmux versions may leave trailing fields out or empty (e.g. no current path
+// or mode flags); such lines are padded rather than dropped, as long as the
+// target and command are present. A missing pane id falls back to the target.
 func parseTmuxPanes(out []byte) []rawPane {
 	var raw []rawPane
 	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
 		if line == "" {
 			continue
 		}
-		fields := strings.SplitN(line, "\t", 7)
-		if len(fields) < 7 {
+		fields := strings.SplitN(line, "\t", tmuxPaneFields)
+		if len(fields) < 2 || fields[0] == "" {
+			debugf("list-panes: skipping line with %d fields: %q", len(fields), line)
 			continue
 		}
-		target, cmd, path, pidStr, windowName, focused, paneID := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+		if len(fields) < tmuxPaneFields {
+			debugf("list-panes: padding line with %d fields: %q", len(fields), line)
+			fields = append(fields, make([]string, tmuxPaneFields-len(fields))...)
+		}
+		target, cmd, path, pidStr, windowName, focused, paneID, inMode, altOn, activity := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6], fields[7], fields[8], fields[9]
+		if paneID == "" {
+			paneID = target
+		}
 		pid, _ := strconv.Atoi(pidStr)
+		activitySec, _ := strconv.ParseInt(activity, 10, 64)
+		// Clean the path so "~/app" and "~/app/" are one workspace.
+		if path != "" {
+			path = filepath.Clean(path)
+		}
 		session, window, pane := ParseTarget(target)
-		raw = append(raw, rawPane{paneID, target, session, window, windowName, pane, path, cmd, pid, focused == "111"})
+		raw = append(raw, rawPane{
+			paneID: paneID, target: target, session: session, window: window,
+			windowName: windowName, pane: pane, path: path, cmd: cmd, pid: pid,
+			windowFocused: focused == "111", inMode: inMode == "1", altScreen: altOn == "1",
+			activity: activitySec,
+		})
 	}
 	return raw
 }
 
+// excludePanes drops panes whose session or working directory matches the
+// user's exclude_sessions / exclude_paths patterns.
+func excludePanes(raw []rawPane, cfg config.Config) []rawPane {
+	if len(cfg.ExcludeSessions) == 0 && len(cfg.ExcludePaths) == 0 {
+		return raw
+	}
+	kept := raw[:0]
+	for _, r := range raw {
+		if config.Matches(cfg.ExcludeSessions, r.session) || config.Matches(cfg.ExcludePaths, r.path) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
 // resolveAgentPanes filters raw panes to only those running a registered agent.
 // Uses the process table to resolve agents that run under a generic command
 // (e.g. gemini runs as "node").
+//
+// With detect_container, panes running docker/podman/devcontainer are also
+// kept when their screen shows a known agent; see containerAgent.
 func resolveAgentPanes(raw []rawPane, pt *provider.ProcessTable) []rawPane {
+	detectContainer := config.Get().DetectContainer
+	if detectContainer {
+		forgetContainerAgents(raw)
+	}
+	forgetResolved(pt)
 	var agents []rawPane
 	for _, r := range raw {
-		cmd := provider.Resolve(r.cmd, r.pid, pt)
+		// Without the pane's pid (a short list-panes line) its processes
+		// are unknown: match the command alone, as when ps fails.
+		procs := pt
+		if r.pid == 0 {
+			procs = &provider.ProcessTable{}
+		}
+		cmd, pid := ResolveProvider(r.cmd, r.pid, procs)
+		if blocker := blockingKind(r.cmd); blocker != "" {
+			// tmux reports the pager or editor, which hides an agent
+			// running as the pane's own process; the agent waits until it
+			// quits.
+			if cmd == "" {
+				cmd, pid = provider.ResolvePID(procs.Args[r.pid], r.pid, procs)
+			}
+			if cmd != "" && runsUnder(procs, pid, r.cmd) {
+				r.blockedBy = blocker
+			}
+		}
+		if cmd == "" && detectContainer {
+			if name := containerAgent(r); name != "" {
+				r.container, r.cmd = r.cmd, name
+				agents = append(agents, r)
+			}
+			continue
+		}
 		if cmd == "" {
 			continue
 		}
+		if pid == r.pid && !agentRunning(r.pid, procs) {
+			debugf("%s: %s has exited (pane_current_command is stale)", r.target, cmd)
+			continue
+		}
+		// A direct command match names the pane's own process, which is
+		// usually the shell the agent was started from; read the args of
+		// the agent itself.
+		if pid == r.pid && !agentProcess(procs, pid) {
+			if _, child := provider.ResolvePID("", r.pid, procs); child != 0 {
+				pid = child
+			}
+		}
 		r.cmd = cmd
+		r.args = procs.Args[pid]
+		r.model = provider.ModelFromArgs(cmd, r.args)
 		agents = append(agents, r)
 	}
 	return agents
 }
 
-// attentionRe matches attention heuristic phrases in captured pane content.
-var attentionRe = regexp.MustCompile(`Do you want to proceed\?|Do you want to allow|Allow once|press Enter to approve|Enter to select|Type something|Esc to cancel|I'll wait for your|waiting for your response|Let me know when|Please let me know|What would you like|How would you like|Should I proceed|Would you like me to|please provide|please specify|I need more information|Could you clarify|awaiting your|ready when you are|let me know if you'd like|Feel free to ask|Is there anything else|What else can I help|Want me to|Shall I|Do you want me to|Ready to proceed`)
+// pagers are the commands an agent may open to show long output; editors
+// those it may open for the user to write something (a commit message, a
+// long prompt).
+var (
+	pagers  = []string{"less", "more", "most"}
+	editors = []string{"vi", "vim", "nvim", "nano", "emacs", "hx", "micro", "kak"}
+)
+
+// blockingKind reports whether cmd, a pane_current_command, is a pager (one
+// of pagers or $PAGER) or an editor (one of editors, $VISUAL or $EDITOR),
+// returning "pager", "editor" or "".
+func blockingKind(cmd string) string {
+	switch {
+	case slices.Contains(pagers, cmd) || envCommand("PAGER") == cmd:
+		return "pager"
+	case slices.Contains(editors, cmd) || envCommand("VISUAL") == cmd || envCommand("EDITOR") == cmd:
+		return "editor"
+	}
+	return ""
+}
+
+// envCommand returns the program name of the command in environment
+// variable key, e.g. "vim" for EDITOR="/usr/bin/vim -f".
+func envCommand(key string) string {
+	fields := strings.Fields(os.Getenv(key))
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
+
+// runsUnder reports whether a process named cmd descends from pid, e.g. the
+// editor an agent opened through git. With no process table nothing does.
+func runsUnder(pt *provider.ProcessTable, pid int, cmd string) bool {
+	for _, child := range pt.Children[pid] {
+		if filepath.Base(pt.Comm[child]) == cmd || runsUnder(pt, child, cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// agentRunning reports whether an agent process still exists for the pane
+// whose root process is pid. tmux's pane_current_command lags behind a few
+// hundred milliseconds after the agent exits, so a direct command match is
+// confirmed against the process table: the pane process itself, or one of
+// its children, must still be an agent. With no process table (ps failed)
+// the match is trusted.
+func agentRunning(pid int, pt *provider.ProcessTable) bool {
+	if len(pt.Comm) == 0 {
+		return true
+	}
+	if agentProcess(pt, pid) {
+		return true
+	}
+	return provider.Resolve("", pid, pt) != ""
+}
+
+// agentProcess reports whether pid itself is an agent, by its command or
+// its command line.
+func agentProcess(pt *provider.ProcessTable, pid int) bool {
+	return provider.IsAgent(pt.Comm[pid]) || provider.IsAgent(pt.Args[pid])
+}
+
+// promptRe matches interactive prompts (approvals, selections) that block the
+// agent until the user answers.
+var promptRe = regexp.MustCompile(`Do you want to proceed\?|Do you want to allow|Allow once|press Enter to approve|Enter to select|Type something|Esc to cancel`)
+
+// questionRe matches conversational phrases an agent uses when handing the
+// turn back with a question. Agents that ask rhetorical questions mid-output
+// can opt out via provider.QuestionHeuristic.
+var questionRe = regexp.MustCompile(`I'll wait for your|waiting for your response|Let me know when|Please let me know|What would you like|How would you like|Should I proceed|Would you like me to|please provide|please specify|I need more information|Could you clarify|awaiting your|ready when you are|let me know if you'd like|Feel free to ask|Is there anything else|What else can I help|Want me to|Shall I|Do you want me to|Ready to proceed`)
+
+// authRe matches login and API key prompts common to agent CLIs, on the last
+// few lines of the screen only (see authWindow). Providers add their own
+// phrases via provider.AuthPrompter.
+var authRe = regexp.MustCompile(`(?i)please log ?in|not logged in|log in to continue|sign in to continue|paste (your )?(api key|code)|enter (your )?api key|api key (is )?required|authentication required|invalid api key|https://\S*oauth\S*`)
 
 // listTmuxPanes runs tmux list-panes and returns raw output.
 func listTmuxPanes() ([]byte, error) {
-	return exec.Command("tmux", "list-panes", "-a", "-F",
-		"#{session_name}:#{window_index}.#{pane_index}\t#{pane_current_command}\t#{pane_current_path}\t#{pane_pid}\t#{window_name}\t#{window_active}#{?session_attached,1,0}#{pane_active}\t#{pane_id}").Output()
+	return tmuxOutput("list-panes", "-a", "-F",
+		"#{session_name}:#{window_index}.#{pane_index}\t#{pane_current_command}\t#{pane_current_path}\t#{pane_pid}\t#{window_name}\t#{window_active}#{?session_attached,1,0}#{pane_active}\t#{pane_id}\t#{pane_in_mode}\t#{alternate_on}\t#{window_activity}")
 }
 
 // loadProcessTable snapshots the process tree via a single ps call.
 func loadProcessTable() provider.ProcessTable {
-	out, err := exec.Command("ps", "-eo", "pid=,ppid=,command=").Output()
+	out, err := cmdr.Output(envOr("AGENTMUX_PS", "ps"), "-eo", "pid=,ppid=,command=")
 	if err != nil {
 		return provider.ProcessTable{
 			Children: make(map[int][]int),
@@ -102,7 +374,7 @@ func fetchPanes() ([]Pane, error) {
 		return nil, fmt.Errorf("tmux list-panes: %w", tmuxErr)
 	}
 
-	raw := resolveAgentPanes(parseTmuxPanes(tmuxOut), &pt)
+	raw := resolveAgentPanes(excludePanes(parseTmuxPanes(tmuxOut), config.Get()), &pt)
 	panes := make([]Pane, len(raw))
 	for i, r := range raw {
 		panes[i] = Pane{
@@ -118,32 +390,130 @@ func fetchPanes() ([]Pane, error) {
 			WindowActive: r.windowFocused,
 			Order:        i,
 			Provider:     r.cmd,
+			Model:        r.model,
+			Args:         r.args,
+			Container:    r.container,
+			BlockedBy:    r.blockedBy,
+			InMode:       r.inMode,
+			AltScreen:    r.altScreen,
+			Activity:     r.activity,
+		}
+	}
+	return panes, nil
+}
+
+// ListOtherPanes returns the panes no provider claims, with Command set to
+// what tmux reports running in them. It exists to debug detection: seeing
+// "node" or "python" where an agent was expected explains a missing pane.
+func ListOtherPanes() ([]Pane, error) {
+	out, err := listTmuxPanes()
+	if err != nil {
+		return nil, fmt.Errorf("tmux list-panes: %w", err)
+	}
+	pt := loadProcessTable()
+	var panes []Pane
+	for _, r := range excludePanes(parseTmuxPanes(out), config.Get()) {
+		if provider.Resolve(r.cmd, r.pid, &pt) != "" {
+			continue
+		}
+		if config.Get().DetectContainer && containerAgent(r) != "" {
+			continue
 		}
+		panes = append(panes, Pane{
+			PaneID:     r.paneID,
+			Target:     r.target,
+			Session:    r.session,
+			Window:     r.window,
+			WindowName: r.windowName,
+			Pane:       r.pane,
+			Path:       r.path,
+			PID:        r.pid,
+			Command:    r.cmd,
+			Order:      len(panes),
+		})
 	}
 	return panes, nil
 }
 
-// capturePaneContent captures the last 10 lines of a tmux pane and returns
-// a content hash and whether the content matches attention heuristics.
-func capturePaneContent(target string) (hash string, attention bool) {
-	out, err := exec.Command("tmux", "capture-pane", "-t", target, "-p", "-S", "-10").Output()
+// capturePaneContent captures the last 10 lines of a tmux pane (more if the
+// provider asks for it via BusyScanLines, for its busy check only) and runs
+// Detect on them: a content hash, the attention/busy/auth heuristics and the
+// provider's descriptive status label.
+//
+// Full-screen TUIs on the alternate screen may draw their status anywhere, so
+// with alt_screen_full_capture the whole visible screen is scanned instead.
+// That costs a larger capture and makes the hash sensitive to any redraw on
+// screen (clocks, animations), which can read as activity.
+//
+// A pane whose window has had no output since its last capture keeps that
+// capture's results without running capture-pane.
+func capturePaneContent(p *Pane) {
+	args := []string{"capture-pane", "-t", p.Target, "-p"}
+	if !fullCapture(p) {
+		lines := max(captureLines, provider.BusyScanLines(p.Provider))
+		args = append(args, "-S", "-"+strconv.Itoa(lines))
+	}
+	if reuseDetection(p) {
+		return
+	}
+	captured := time.Now().Unix()
+	out, err := tmuxOutput(args...)
 	if err != nil {
-		return "", false
+		return
 	}
-	content := bytes.TrimRight(out, "\n")
-	h := sha256.Sum256(content)
-	return fmt.Sprintf("%x", h[:8]), attentionRe.Match(content)
+	Detect(p, cleanCapture(bytes.TrimRight(out, "\n")))
+	noteCapture(p, captured)
 }
 
-// CaptureContent populates ContentHash and HeuristicAttention on each pane
-// by capturing the last 10 lines in parallel.
+// cleanCapture makes a status capture safe to match and to show (the
+// attention line and labels come from it), as the preview is: invalid UTF-8
+// is replaced and control characters other than newline and tab are dropped.
+func cleanCapture(out []byte) []byte {
+	return bytes.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, bytes.ToValidUTF8(out, []byte("\uFFFD")))
+}
+
+// captureLines is how many lines capturePaneContent takes from the bottom of
+// a pane by default.
+const captureLines = 10
+
+// fullCapture reports whether capturePaneContent takes p's whole visible
+// screen (see alt_screen_full_capture) rather than its last lines.
+func fullCapture(p *Pane) bool {
+	return p.AltScreen && config.Get().AltScreenFullCapture
+}
+
+// normalizeLines splits captured content into lines with runs of whitespace
+// collapsed, so phrases split by partial redraws or padded cells still match.
+func normalizeLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return lines
+}
+
+// CaptureContent populates ContentHash and the heuristic fields on
+// each pane by capturing the last 10 lines in parallel. Panes in copy mode are
+// skipped: their captured view is frozen and would misclassify status.
 func CaptureContent(panes []Pane) {
+	forgetDetections(panes)
 	var wg sync.WaitGroup
 	for i := range panes {
+		if panes[i].InMode {
+			continue
+		}
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			panes[idx].ContentHash, panes[idx].HeuristicAttention = capturePaneContent(panes[idx].Target)
+			capturePaneContent(&panes[idx])
 		}(i)
 	}
 	wg.Wait()
@@ -169,42 +539,402 @@ func ListPanes() ([]Pane, error) {
 
 // CapturePane captures the visible content of a tmux pane.
 func CapturePane(target string, lines int) (string, error) {
-	out, err := exec.Command("tmux", "capture-pane", "-t", target, "-e", "-p", "-S",
-		fmt.Sprintf("-%d", lines)).Output()
+	out, err := tmuxOutput("capture-pane", "-t", target, "-e", "-p", "-S",
+		fmt.Sprintf("-%d", lines))
 	if err != nil {
 		return "", fmt.Errorf("capture-pane %s: %w", target, err)
 	}
-	return string(out), nil
+	// Agents printing binary data would otherwise feed invalid UTF-8 to
+	// the width calculations and the renderer.
+	return strings.ToValidUTF8(string(out), "\uFFFD"), nil
 }
 
-// SwitchToPane switches the tmux client to the given pane.
+// SwitchToPane switches the tmux client agent-mux runs in to the given pane.
+// With several clients attached, naming the client keeps tmux from moving
+// whichever one it considers most recent.
 func SwitchToPane(target string) error {
 	session, window, _ := ParseTarget(target)
 	sessionWindow := session + ":" + window
-	if err := exec.Command("tmux", "switch-client", "-t", sessionWindow).Run(); err != nil {
+	args := []string{"switch-client", "-t", sessionWindow}
+	if client := currentClient(); client != "" {
+		args = append(args, "-c", client)
+	}
+	if err := tmuxRun(args...); err != nil {
 		return fmt.Errorf("switch-client: %w", err)
 	}
-	if err := exec.Command("tmux", "select-pane", "-t", target).Run(); err != nil {
+	if err := tmuxRun("select-pane", "-t", target); err != nil {
 		return fmt.Errorf("select-pane: %w", err)
 	}
 	return nil
 }
 
+// ClearHistory drops a pane's scrollback and sends Ctrl+L so the program in
+// it redraws on a clean screen.
+func ClearHistory(target string) error {
+	if err := tmuxRun("clear-history", "-t", target); err != nil {
+		return fmt.Errorf("clear-history: %w", err)
+	}
+	if err := tmuxRun("send-keys", "-t", target, "C-l"); err != nil {
+		return fmt.Errorf("send-keys: %w", err)
+	}
+	return nil
+}
+
+// CopyToClipboard stores text in a tmux paste buffer and, with -w, hands it
+// to the terminal's clipboard (via OSC 52 when set-clipboard allows it).
+func CopyToClipboard(text string) error {
+	if err := tmuxRun("set-buffer", "-w", "--", text); err != nil {
+		return fmt.Errorf("set-buffer: %w", err)
+	}
+	return nil
+}
+
+// SwitchCommand returns a shell command that takes a tmux client to target,
+// for sharing with someone attached to the same server.
+func SwitchCommand(target string) string {
+	session, window, _ := ParseTarget(target)
+	return "tmux switch-client -t " + shellQuote(session+":"+window) + ` \; select-pane -t ` + shellQuote(target)
+}
+
+// CurrentSession returns the name of the tmux session agent-mux runs in.
+func CurrentSession() (string, error) {
+	args := []string{"display-message", "-p"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	out, err := tmuxExec(append(args, "#{session_name}")...)
+	if err != nil {
+		return "", fmt.Errorf("display-message: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// PopupSupported reports whether the tmux server is new enough (3.2+) for
+// display-popup.
+func PopupSupported() bool {
+	out, err := tmuxExec("-V")
+	if err != nil {
+		return false
+	}
+	// e.g. "tmux 3.3a", "tmux next-3.4"
+	v := strings.TrimSpace(strings.TrimPrefix(string(out), "tmux "))
+	v = strings.TrimPrefix(v, "next-")
+	major, rest, _ := strings.Cut(v, ".")
+	maj, err := strconv.Atoi(major)
+	if err != nil {
+		return false
+	}
+	minor, _ := strconv.Atoi(strings.TrimRightFunc(rest, func(r rune) bool { return r < '0' || r > '9' }))
+	return maj > 3 || (maj == 3 && minor >= 2)
+}
+
+// PopupPane shows target's scrollback in a tmux popup, paged with less and
+// opened at the bottom, so it can be read without switching to it. The call
+// returns once the popup is closed.
+func PopupPane(target string) error {
+	tmux := shellQuote(envOr("AGENTMUX_TMUX", "tmux"))
+	if tmuxSocket != "" {
+		tmux += " -S " + shellQuote(tmuxSocket)
+	}
+	script := tmux + " capture-pane -p -e -J -S - -t " + shellQuote(target) + " | less -R +G"
+	err := tmuxRun("display-popup", "-E", "-w", "90%", "-h", "90%", script)
+	if err != nil {
+		return fmt.Errorf("display-popup: %w", err)
+	}
+	return nil
+}
+
+// shellQuote wraps s in single quotes for /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// currentClient returns the name of the tmux client agent-mux is displayed
+// in, or "" when it can't be determined (e.g. started outside tmux). Asking
+// display-message would get whichever client tmux considers best, the same
+// guess switch-client makes without -c.
+func currentClient() string {
+	if os.Getenv("TMUX") == "" {
+		return ""
+	}
+	out, err := tmuxOutput("list-clients", "-F", "#{client_name}\t#{client_tty}\t#{client_session}\t#{client_activity}")
+	if err != nil {
+		return ""
+	}
+	tty, _ := os.Readlink("/proc/self/fd/0")
+	var session string
+	if os.Getenv("TMUX_PANE") != "" {
+		session, _ = CurrentSession()
+	}
+	return pickClient(out, tty, session)
+}
+
+// pickClient picks from list-clients output the client on terminal tty, or
+// failing that the most recently active client showing session. It returns
+// "" when neither matches.
+func pickClient(out []byte, tty, session string) string {
+	best, bestActivity := "", int64(-1)
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		name, clientTTY, clientSession := fields[0], fields[1], fields[2]
+		if tty != "" && clientTTY == tty {
+			return name
+		}
+		if session == "" || clientSession != session {
+			continue
+		}
+		if activity, _ := strconv.ParseInt(fields[3], 10, 64); activity > bestActivity {
+			best, bestActivity = name, activity
+		}
+	}
+	return best
+}
+
+// SendKeys types text into a tmux pane literally and presses Enter.
+func SendKeys(target, text string) error {
+	if err := tmuxRun("send-keys", "-t", target, "-l", "--", escapeTmuxArg(text)); err != nil {
+		return fmt.Errorf("send-keys: %w", err)
+	}
+	if err := tmuxRun("send-keys", "-t", target, "Enter"); err != nil {
+		return fmt.Errorf("send-keys: %w", err)
+	}
+	return nil
+}
+
+// SendKey presses a single named tmux key in a pane, e.g. "Up", "C-r" or
+// "Escape". Unlike SendKeys the name is not typed as text.
+func SendKey(target, key string) error {
+	if key == "" {
+		return fmt.Errorf("send-keys: no key")
+	}
+	if err := tmuxRun("send-keys", "-t", target, "--", key); err != nil {
+		return fmt.Errorf("send-keys %s: %w", key, err)
+	}
+	return nil
+}
+
+// Ping checks that the program in a pane still reads input: it types a
+// space, waits, and reports whether the screen or the cursor moved. The
+// space is erased again only when it was echoed at the cursor; a program
+// that didn't show it (busy, hung) may still read it later, and a backspace
+// then would delete the last character of the user's draft instead. Don't
+// ping a pane showing a prompt: the space may pick an option.
+func Ping(target string, wait time.Duration) (bool, error) {
+	before, err := pingScreen(target)
+	if err != nil {
+		return false, err
+	}
+	if err := SendKey(target, "Space"); err != nil {
+		return false, err
+	}
+	cmdr.Sleep(wait)
+	after, err := pingScreen(target)
+	if err != nil {
+		return false, err
+	}
+	if after.y == before.y && after.x == before.x+1 {
+		if err := SendKey(target, "BSpace"); err != nil {
+			return true, err
+		}
+	}
+	return after != before, nil
+}
+
+// pingState is what Ping compares: the cursor and the visible screen.
+type pingState struct {
+	x, y    int
+	content string
+}
+
+func pingScreen(target string) (pingState, error) {
+	cursor, err := tmuxOutput("display-message", "-p", "-t", target, "#{cursor_x},#{cursor_y}")
+	if err != nil {
+		return pingState{}, fmt.Errorf("display-message %s: %w", target, err)
+	}
+	content, err := tmuxOutput("capture-pane", "-t", target, "-p")
+	if err != nil {
+		return pingState{}, fmt.Errorf("capture-pane %s: %w", target, err)
+	}
+	xs, ys, _ := strings.Cut(strings.TrimSpace(string(cursor)), ",")
+	x, _ := strconv.Atoi(xs)
+	y, _ := strconv.Atoi(ys)
+	return pingState{x: x, y: y, content: string(content)}, nil
+}
+
+// escapeTmuxArg protects an argument from tmux's command parser, which treats
+// a trailing ";" as a command separator even when passed as a single argv.
+func escapeTmuxArg(s string) string {
+	if strings.HasSuffix(s, ";") {
+		return s[:len(s)-1] + `\;`
+	}
+	return s
+}
+
+// WindowName returns the name of the window containing paneID and whether
+// tmux is automatically renaming it.
+func WindowName(paneID string) (name string, autoRename bool, err error) {
+	out, err := tmuxExec("display-message", "-p", "-t", paneID, "#{window_name}\t#{automatic-rename}")
+	if err != nil {
+		return "", false, fmt.Errorf("display-message: %w", err)
+	}
+	name, auto, _ := strings.Cut(strings.TrimRight(string(out), "\n"), "\t")
+	return name, auto == "1", nil
+}
+
+// RenameWindow renames the window containing paneID.
+func RenameWindow(paneID, name string) error {
+	return tmuxRun("rename-window", "-t", paneID, name)
+}
+
+// RestoreWindowName undoes RenameWindow: it re-enables automatic renaming if
+// it was on, otherwise restores the original name.
+func RestoreWindowName(paneID, name string, autoRename bool) error {
+	if autoRename {
+		return tmuxRun("set-option", "-w", "-t", paneID, "automatic-rename", "on")
+	}
+	return RenameWindow(paneID, name)
+}
+
+// NewAgentPane starts command in dir in a new background window of session
+// ("" for the current one) and returns the new pane's target.
+func NewAgentPane(session, dir, command string) (string, error) {
+	args := []string{"new-window", "-d", "-P", "-F", "#{session_name}:#{window_index}.#{pane_index}", "-c", dir}
+	if session != "" {
+		args = append(args, "-t", session+":")
+	}
+	out, err := tmuxExec(append(args, command)...)
+	if err != nil {
+		return "", fmt.Errorf("new-window: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// TileTargets moves the given panes into a new window, next to the window of
+// the first, tiled side by side, and returns the new window's target
+// ("session:@id", which survives renumber-windows). Pass
+// pane ids (%3): joining renumbers the panes left behind, so index targets go
+// stale. A window whose last pane is joined away closes, as tmux does. Panes
+// that fail to join are reported together; the window is removed if none
+// joined.
+func TileTargets(targets []string) (string, error) {
+	if len(targets) == 0 {
+		return "", fmt.Errorf("no panes to tile")
+	}
+	after, err := tmuxOutput("display-message", "-p", "-t", targets[0], "#{window_id}")
+	if err != nil {
+		return "", fmt.Errorf("display-message: %w", err)
+	}
+	// The new window starts with a placeholder shell pane: tmux has no empty
+	// windows. It goes once the others are in.
+	out, err := tmuxExec("new-window", "-d", "-a", "-t", strings.TrimSpace(string(after)), "-n", "attention",
+		"-P", "-F", "#{session_name}:#{window_id}\t#{pane_id}")
+	if err != nil {
+		return "", fmt.Errorf("new-window: %w", err)
+	}
+	window, placeholder, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+
+	var failed []string
+	for _, t := range targets {
+		if err := tmuxRun("join-pane", "-d", "-s", t, "-t", window); err != nil {
+			failed = append(failed, t)
+			continue
+		}
+		// Re-tile after each join so the next one has room to split.
+		_ = tmuxRun("select-layout", "-t", window, "tiled")
+	}
+	if len(failed) == len(targets) {
+		_ = tmuxRun("kill-window", "-t", window)
+		return "", fmt.Errorf("join-pane: could not move %s", strings.Join(failed, ", "))
+	}
+	_ = tmuxRun("kill-pane", "-t", placeholder)
+	_ = tmuxRun("select-layout", "-t", window, "tiled")
+	if len(failed) > 0 {
+		return window, fmt.Errorf("join-pane: could not move %s", strings.Join(failed, ", "))
+	}
+	return window, nil
+}
+
+// ClosingWindows returns the windows ("session:index") TileTargets would
+// close by moving targets (pane ids) away: those all of whose panes are among
+// them.
+func ClosingWindows(targets []string) ([]string, error) {
+	out, err := tmuxOutput("list-panes", "-a", "-F", "#{pane_id}\t#{session_name}:#{window_index}")
+	if err != nil {
+		return nil, fmt.Errorf("list-panes: %w", err)
+	}
+	moving := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		moving[t] = true
+	}
+	var windows []string
+	kept := make(map[string]bool) // window keeps a pane
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		id, window, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if _, seen := kept[window]; !seen {
+			windows = append(windows, window)
+		}
+		kept[window] = kept[window] || !moving[id]
+	}
+	return slices.DeleteFunc(windows, func(w string) bool { return kept[w] }), nil
+}
+
+// stopKeyDelay paces the keys of a quit sequence so the agent handles each
+// one (a ctrl+c that clears the input, then the command) in turn.
+const stopKeyDelay = 150 * time.Millisecond
+
+// stopPollInterval is how often StopPane checks whether the agent exited.
+const stopPollInterval = 100 * time.Millisecond
+
+// StopPane asks the agent in p to exit by pressing its provider's quit keys,
+// waits about wait for the agent process to go away, then kills the pane as
+// KillPane does, unless it closed with the agent. exited reports whether the
+// agent quit on its own before the kill.
+func StopPane(p Pane, wait time.Duration) (exited bool, err error) {
+	for _, key := range provider.QuitKeys(p.Provider) {
+		if err := SendKey(p.Target, key); err != nil {
+			return false, err
+		}
+		cmdr.Sleep(stopKeyDelay)
+	}
+	for waited := time.Duration(0); ; waited += stopPollInterval {
+		pt := loadProcessTable()
+		if len(pt.Comm) > 0 && !agentRunning(p.PID, &pt) {
+			exited = true
+			break
+		}
+		if waited >= wait {
+			break
+		}
+		cmdr.Sleep(stopPollInterval)
+	}
+	// An agent running as the pane's own process takes the pane with it.
+	if tmuxRun("display-message", "-p", "-t", p.PaneID, "") != nil {
+		return exited, nil
+	}
+	return exited, KillPane(p.Target)
+}
+
 // KillPane kills a tmux pane. If it's the only pane in the window, kills the window instead.
 func KillPane(target string) error {
 	session, window, _ := ParseTarget(target)
 	sessionWindow := session + ":" + window
 
-	out, err := exec.Command("tmux", "list-panes", "-t", sessionWindow).Output()
+	out, err := tmuxExec("list-panes", "-t", sessionWindow)
 	if err != nil {
 		return fmt.Errorf("list-panes: %w", err)
 	}
 	paneCount := len(strings.Split(strings.TrimSpace(string(out)), "\n"))
 
 	if paneCount <= 1 {
-		return exec.Command("tmux", "kill-window", "-t", sessionWindow).Run()
+		return tmuxRun("kill-window", "-t", sessionWindow)
 	}
-	return exec.Command("tmux", "kill-pane", "-t", target).Run()
+	return tmuxRun("kill-pane", "-t", target)
 }
 
 // parseTarget splits "foo:2.1" into session="foo", window="2", pane="1".
@@ -0,0 +1,612 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/leo/agent-mux/internal/config"
+	"github.com/leo/agent-mux/internal/provider"
+)
+
+// TestMain keeps the user's config file out of the tests: every test sees
+// the defaults.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "agent-mux-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fakeCommander answers commands from a script instead of running them. Each
+// call is recorded as the program's arguments joined by spaces; reply, when
+// set, answers it, otherwise the call succeeds with no output.
+type fakeCommander struct {
+	calls  []string
+	slept  []time.Duration
+	reply  func(call string, n int) ([]byte, error) // n counts earlier identical calls
+	counts map[string]int
+}
+
+func (f *fakeCommander) Output(name string, args ...string) ([]byte, error) {
+	call := strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	n := f.counts[call]
+	f.counts[call]++
+	if f.reply == nil {
+		return nil, nil
+	}
+	return f.reply(call, n)
+}
+
+func (f *fakeCommander) Sleep(d time.Duration) { f.slept = append(f.slept, d) }
+
+// useFake routes the package's commands to f for the rest of the test.
+func useFake(t *testing.T, f *fakeCommander) {
+	t.Helper()
+	prev := cmdr
+	cmdr = f
+	t.Cleanup(func() { cmdr = prev })
+}
+
+func TestTmuxOutputRetriesTransientFailure(t *testing.T) {
+	f := &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+		if n == 0 {
+			return nil, errors.New("exit status 1: lost server")
+		}
+		return []byte("ok\n"), nil
+	}}
+	useFake(t, f)
+
+	out, err := tmuxOutput("list-panes", "-a")
+	if err != nil || string(out) != "ok\n" {
+		t.Fatalf("tmuxOutput = %q, %v; want the second call's output", out, err)
+	}
+	if len(f.calls) != 2 {
+		t.Errorf("ran %d commands, want 2: %q", len(f.calls), f.calls)
+	}
+	if len(f.slept) != 1 || f.slept[0] != tmuxRetryDelays[0] {
+		t.Errorf("slept %v, want [%v]", f.slept, tmuxRetryDelays[0])
+	}
+}
+
+func TestTmuxOutputGivesUpAfterRetries(t *testing.T) {
+	f := &fakeCommander{reply: func(string, int) ([]byte, error) {
+		return nil, errors.New("exit status 1: server exited unexpectedly")
+	}}
+	useFake(t, f)
+
+	if _, err := tmuxOutput("list-panes", "-a"); err == nil {
+		t.Fatal("tmuxOutput succeeded, want the last error")
+	}
+	if want := 1 + len(tmuxRetryDelays); len(f.calls) != want {
+		t.Errorf("ran %d commands, want %d", len(f.calls), want)
+	}
+}
+
+func TestTmuxOutputDoesNotRetryPermanentFailure(t *testing.T) {
+	f := &fakeCommander{reply: func(string, int) ([]byte, error) {
+		return nil, errors.New("exit status 1: can't find pane: %9")
+	}}
+	useFake(t, f)
+
+	if _, err := tmuxOutput("capture-pane", "-t", "%9", "-p"); err == nil {
+		t.Fatal("tmuxOutput succeeded, want the error")
+	}
+	if len(f.calls) != 1 || len(f.slept) != 0 {
+		t.Errorf("ran %d commands and slept %v, want one call and no retry", len(f.calls), f.slept)
+	}
+}
+
+func TestTmuxExecPassesSocket(t *testing.T) {
+	f := &fakeCommander{}
+	useFake(t, f)
+	SetSocket("/tmp/agents.sock")
+	t.Cleanup(func() { SetSocket("") })
+
+	_ = tmuxRun("list-sessions")
+	if want := "-S /tmp/agents.sock list-sessions"; len(f.calls) != 1 || f.calls[0] != want {
+		t.Errorf("calls = %q, want [%q]", f.calls, want)
+	}
+}
+
+func TestResolveAgentPanesReadsAgentArgs(t *testing.T) {
+	// The shell started claude in the foreground, so tmux reports "claude"
+	// for the pane while its pid is the shell's.
+	pt := provider.ParseProcessTable(`
+  100     1 -bash
+  101   100 claude --model opus --resume 0b7c2f9e
+  200     1 claude --model sonnet
+`)
+	raw := []rawPane{
+		{target: "main:1.0", cmd: "claude", pid: 100},
+		{target: "main:2.0", cmd: "claude", pid: 200},
+	}
+	got := resolveAgentPanes(raw, &pt)
+	if len(got) != 2 {
+		t.Fatalf("resolved %d panes, want 2", len(got))
+	}
+	for i, want := range []struct{ args, model string }{
+		{"claude --model opus --resume 0b7c2f9e", "opus"},
+		{"claude --model sonnet", "sonnet"},
+	} {
+		if got[i].args != want.args || got[i].model != want.model {
+			t.Errorf("%s: args %q, model %q; want %q, %q", got[i].target, got[i].args, got[i].model, want.args, want.model)
+		}
+	}
+}
+
+func TestResolveAgentPanesDropsExitedAgent(t *testing.T) {
+	targets := func(raw []rawPane) []string {
+		var out []string
+		for _, r := range raw {
+			out = append(out, r.target)
+		}
+		return out
+	}
+	raw := []rawPane{
+		{target: "main:1.0", cmd: "claude", pid: 4100}, // started in the foreground
+		{target: "main:2.0", cmd: "zsh", pid: 4200},    // hosted under the shell
+	}
+
+	running := provider.ParseProcessTable(`
+ 4100     1 -zsh
+ 4101  4100 claude --continue
+ 4200     1 -zsh
+ 4201  4200 node /usr/local/lib/node_modules/@google/gemini-cli/dist/index.js
+`)
+	if got, want := targets(resolveAgentPanes(raw, &running)), []string{"main:1.0", "main:2.0"}; !slices.Equal(got, want) {
+		t.Fatalf("while running: resolved %q, want %q", got, want)
+	}
+
+	// Both agents quit: tmux still reports "claude" for the first pane for
+	// a moment, and the second pane's resolved child is cached.
+	exited := provider.ParseProcessTable(`
+ 4100     1 -zsh
+ 4200     1 -zsh
+`)
+	if got := targets(resolveAgentPanes(raw, &exited)); len(got) != 0 {
+		t.Errorf("after exit: resolved %q, want none", got)
+	}
+
+	// Without a process table the stale command is all there is to go on.
+	if got, want := targets(resolveAgentPanes(raw, &provider.ProcessTable{})), []string{"main:1.0"}; !slices.Equal(got, want) {
+		t.Errorf("without ps: resolved %q, want %q", got, want)
+	}
+}
+
+func TestResolveAgentPanesBlockedBy(t *testing.T) {
+	t.Setenv("EDITOR", "/usr/local/bin/zed --wait")
+	pt := provider.ParseProcessTable(`
+ 5100     1 -zsh
+ 5101  5100 claude
+ 5102  5101 less -R /tmp/claude-output.txt
+ 5200     1 claude --continue
+ 5201  5200 git log
+ 5202  5201 less
+ 5300     1 -zsh
+ 5301  5300 less README.md
+ 5400     1 -zsh
+ 5401  5400 claude
+ 5402  5400 less notes.txt
+ 5500     1 -zsh
+ 5501  5500 claude
+ 5502  5501 git commit
+ 5503  5502 vim /src/app/.git/COMMIT_EDITMSG
+ 5600     1 -bash
+ 5601  5600 node /usr/local/lib/node_modules/@google/gemini-cli/dist/index.js
+ 5602  5601 nano /tmp/prompt.md
+ 5700     1 -zsh
+ 5701  5700 nvim main.go
+ 5800     1 -zsh
+ 5801  5800 claude
+ 5802  5801 zed --wait /tmp/claude-prompt.md
+`)
+	tests := []struct {
+		name    string
+		raw     rawPane
+		agent   string // "" when the pane is dropped
+		blocked string
+	}{
+		{"pager under a claude started from the shell", rawPane{cmd: "less", pid: 5100}, "claude", "pager"},
+		{"pager under git under claude", rawPane{cmd: "less", pid: 5200}, "claude", "pager"},
+		{"pager in a plain shell", rawPane{cmd: "less", pid: 5300}, "", ""},
+		{"pager beside the agent, not under it", rawPane{cmd: "less", pid: 5400}, "claude", ""},
+		{"vim opened by git under claude", rawPane{cmd: "vim", pid: 5500}, "claude", "editor"},
+		{"nano under a node-hosted gemini", rawPane{cmd: "nano", pid: 5600}, "gemini", "editor"},
+		{"editor in a plain shell", rawPane{cmd: "nvim", pid: 5700}, "", ""},
+		{"$EDITOR under claude", rawPane{cmd: "zed", pid: 5800}, "claude", "editor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.raw.target = "main:1.0"
+			got := resolveAgentPanes([]rawPane{tt.raw}, &pt)
+			switch {
+			case tt.agent == "" && len(got) != 0:
+				t.Errorf("listed as %s, want dropped", got[0].cmd)
+			case tt.agent == "":
+			case len(got) != 1:
+				t.Errorf("dropped, want %s", tt.agent)
+			case got[0].cmd != tt.agent || got[0].blockedBy != tt.blocked:
+				t.Errorf("agent %q blocked by %q; want %q, %q", got[0].cmd, got[0].blockedBy, tt.agent, tt.blocked)
+			}
+		})
+	}
+
+	for blocker, label := range map[string]string{"pager": "in pager", "editor": "editor open"} {
+		p := Pane{PaneID: "%" + blocker, Provider: "claude", BlockedBy: blocker}
+		Detect(&p, []byte("✶ Cogitating… (12s · esc to interrupt)\n~\n~\n:"))
+		if !p.HeuristicAttention || p.HeuristicBusy || p.StatusLabel != label {
+			t.Errorf("blocked by %s: attention %v, busy %v, label %q; want attention, %s",
+				blocker, p.HeuristicAttention, p.HeuristicBusy, p.StatusLabel, label)
+		}
+	}
+}
+
+func TestPickClient(t *testing.T) {
+	out := []byte("/dev/pts/1\t/dev/pts/1\twork\t1767366200\n" +
+		"/dev/pts/4\t/dev/pts/4\tmain\t1767366100\n" +
+		"/dev/pts/7\t/dev/pts/7\twork\t1767366245\n")
+	tests := []struct {
+		tty, session, want string
+	}{
+		{"/dev/pts/4", "work", "/dev/pts/4"}, // own terminal wins
+		{"", "work", "/dev/pts/7"},           // most recent client on the session
+		{"/dev/pts/9", "main", "/dev/pts/4"},
+		{"", "scratch", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := pickClient(out, tt.tty, tt.session); got != tt.want {
+			t.Errorf("pickClient(tty %q, session %q) = %q, want %q", tt.tty, tt.session, got, tt.want)
+		}
+	}
+}
+
+// stopServer fakes tmux and ps for StopPane: a pane %1 at main:1.0 whose
+// shell (pid 100) runs claude until the agentPolls-th process listing, and
+// which closes with the agent when paneCloses is set.
+func stopServer(agentPolls int, paneCloses bool) *fakeCommander {
+	return &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+		switch {
+		case call == "-eo pid=,ppid=,command=":
+			if n < agentPolls {
+				return []byte("100 1 -bash\n101 100 claude\n"), nil
+			}
+			return []byte("100 1 -bash\n"), nil
+		case strings.HasPrefix(call, "display-message -p -t %1"):
+			if paneCloses {
+				return nil, errors.New("exit status 1: can't find pane: %1")
+			}
+		case call == "list-panes -t main:1":
+			return []byte("0: [80x24] [history 0/2000, 0 bytes] %1 (active)\n1: [80x24] %2\n"), nil
+		}
+		return nil, nil
+	}}
+}
+
+// commandsLike returns the calls of f starting with prefix, in order.
+func commandsLike(f *fakeCommander, prefix string) []string {
+	var out []string
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestStopPane(t *testing.T) {
+	pane := Pane{PaneID: "%1", Target: "main:1.0", PID: 100, Provider: "claude"}
+	wantKeys := []string{
+		"send-keys -t main:1.0 -- C-c",
+		"send-keys -t main:1.0 -- /exit",
+		"send-keys -t main:1.0 -- Enter",
+	}
+	tests := []struct {
+		name       string
+		agentPolls int // listings that still show the agent
+		paneCloses bool
+		wantExited bool
+		wantKill   bool
+	}{
+		{"exits and leaves the shell", 3, false, true, true},
+		{"exits and closes the pane", 3, true, true, false},
+		{"never exits", 1000, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := stopServer(tt.agentPolls, tt.paneCloses)
+			useFake(t, f)
+			exited, err := StopPane(pane, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exited != tt.wantExited {
+				t.Errorf("exited = %v, want %v", exited, tt.wantExited)
+			}
+			if keys := commandsLike(f, "send-keys"); !slices.Equal(keys, wantKeys) {
+				t.Errorf("keys sent: %q, want %q", keys, wantKeys)
+			}
+			kills := commandsLike(f, "kill-")
+			if tt.wantKill && !slices.Equal(kills, []string{"kill-pane -t main:1.0"}) {
+				t.Errorf("kills = %q, want the pane killed", kills)
+			}
+			if !tt.wantKill && len(kills) > 0 {
+				t.Errorf("kills = %q, want none", kills)
+			}
+			polls := f.counts["-eo pid=,ppid=,command="]
+			if limit := int(time.Second/stopPollInterval) + 1; polls > limit {
+				t.Errorf("polled %d times, want at most %d within the wait", polls, limit)
+			}
+			if tt.wantExited && polls != tt.agentPolls+1 {
+				t.Errorf("polled %d times, want to stop at the first listing without the agent", polls)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name           string
+		cursorAfter    string
+		contentAfter   string
+		wantAlive      bool
+		wantBackspaced bool
+	}{
+		{"space echoed", "6,10", "> fix the tests \n", true, true},
+		{"busy agent redraws without echoing", "5,10", "> fix the tests\n✻ Thinking… (3s)\n", true, false},
+		{"nothing moves", "5,10", "> fix the tests\n", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+				switch {
+				case strings.HasPrefix(call, "display-message"):
+					if n == 0 {
+						return []byte("5,10\n"), nil
+					}
+					return []byte(tt.cursorAfter + "\n"), nil
+				case strings.HasPrefix(call, "capture-pane"):
+					if n == 0 {
+						return []byte("> fix the tests\n"), nil
+					}
+					return []byte(tt.contentAfter), nil
+				}
+				return nil, nil
+			}}
+			useFake(t, f)
+			alive, err := Ping("main:1.0", 300*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if alive != tt.wantAlive {
+				t.Errorf("alive = %v, want %v", alive, tt.wantAlive)
+			}
+			want := []string{"send-keys -t main:1.0 -- Space"}
+			if tt.wantBackspaced {
+				want = append(want, "send-keys -t main:1.0 -- BSpace")
+			}
+			if keys := commandsLike(f, "send-keys"); !slices.Equal(keys, want) {
+				t.Errorf("keys sent: %q, want %q", keys, want)
+			}
+		})
+	}
+}
+
+func TestCaptureSkipsQuietWindow(t *testing.T) {
+	f := &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+		return []byte("⏺ Done.\n\n> Would you like me to continue?\n"), nil
+	}}
+	useFake(t, f)
+	t.Cleanup(func() { forgetDetections(nil) })
+
+	captures := func() int { return len(commandsLike(f, "capture-pane")) }
+	const quiet = 1767366245 // long before the captures below
+	p := Pane{PaneID: "%1", Target: "main:1.0", Provider: "claude", Activity: quiet}
+	capturePaneContent(&p)
+	if captures() != 1 || !p.HeuristicAttention {
+		t.Fatalf("first tick: %d captures, attention %v; want a capture that finds the question", captures(), p.HeuristicAttention)
+	}
+
+	next := Pane{PaneID: "%1", Target: "main:1.0", Provider: "claude", Activity: quiet}
+	capturePaneContent(&next)
+	if captures() != 1 {
+		t.Errorf("captured a window with no output since the last capture")
+	}
+	if next.ContentHash != p.ContentHash || !next.HeuristicAttention || next.AttentionLine != p.AttentionLine {
+		t.Errorf("reused detection = %+v, want the previous tick's", next)
+	}
+
+	next.Activity = quiet + 60
+	capturePaneContent(&next)
+	if captures() != 2 {
+		t.Errorf("window with new output not captured again")
+	}
+
+	// Output in the second the capture ran may have missed it.
+	now := time.Now().Unix()
+	p = Pane{PaneID: "%2", Target: "main:2.0", Provider: "claude", Activity: now + 1}
+	capturePaneContent(&p)
+	capturePaneContent(&p)
+	if captures() != 4 {
+		t.Errorf("skipped the capture of a window active as it was captured")
+	}
+}
+
+func TestClosingWindows(t *testing.T) {
+	f := &fakeCommander{reply: func(string, int) ([]byte, error) {
+		return []byte("%1\tmain:1\n%2\tmain:2\n%3\tmain:2\n%4\tmain:3\n%5\tmain:3\n"), nil
+	}}
+	useFake(t, f)
+	got, err := ClosingWindows([]string{"%1", "%2", "%4", "%5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// main:2 keeps %3.
+	if want := []string{"main:1", "main:3"}; !slices.Equal(got, want) {
+		t.Errorf("ClosingWindows = %q, want %q", got, want)
+	}
+}
+
+func TestCaptureCleansContent(t *testing.T) {
+	f := &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+		if strings.HasPrefix(call, "capture-pane") {
+			return []byte("\x89PNG\r\n\x1a\n\xff\xfe\x00\x00\nDo you want to proceed?\x07 \xc3\x28\n"), nil
+		}
+		return []byte("ok\xff\xfe\n"), nil
+	}}
+	useFake(t, f)
+	t.Cleanup(func() { forgetDetections(nil) })
+
+	p := Pane{PaneID: "%1", Target: "main:1.0", Provider: "claude"}
+	capturePaneContent(&p)
+	if want := "Do you want to proceed? �("; p.AttentionLine != want {
+		t.Errorf("AttentionLine = %q, want %q", p.AttentionLine, want)
+	}
+
+	out, err := CapturePane("main:1.0", 10)
+	if err != nil || !utf8.ValidString(out) {
+		t.Errorf("CapturePane = %q, %v; want valid UTF-8", out, err)
+	}
+}
+
+func TestCaptureAltScreen(t *testing.T) {
+	// A full-screen TUI drawing its status at the top, its cells padded
+	// by a partial redraw, above a mostly empty screen.
+	frame := " ~/src/app\n  ✻ Thinking…   (esc  to   interrupt)\n" + strings.Repeat("\n", 30) + " > \n"
+	tests := []struct {
+		name      string
+		altScreen bool
+		full      bool
+		wantArgs  string
+		wantBusy  bool
+	}{
+		{"normal screen", false, true, "capture-pane -t main:1.0 -p -S -10", false},
+		{"alt screen, option off", true, false, "capture-pane -t main:1.0 -p -S -10", false},
+		{"alt screen, option on", true, true, "capture-pane -t main:1.0 -p", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, config.Config{AltScreenFullCapture: tt.full})
+			f := &fakeCommander{reply: func(call string, n int) ([]byte, error) {
+				if strings.HasSuffix(call, "-S -10") {
+					lines := strings.Split(frame, "\n")
+					return []byte(strings.Join(lines[len(lines)-10:], "\n")), nil
+				}
+				return []byte(frame), nil
+			}}
+			useFake(t, f)
+			t.Cleanup(func() { forgetDetections(nil) })
+
+			p := Pane{PaneID: "%1", Target: "main:1.0", Provider: "claude", AltScreen: tt.altScreen}
+			capturePaneContent(&p)
+			if got := commandsLike(f, "capture-pane"); len(got) != 1 || got[0] != tt.wantArgs {
+				t.Errorf("captured with %q, want %q", got, tt.wantArgs)
+			}
+			if p.HeuristicBusy != tt.wantBusy {
+				t.Errorf("busy = %v, want %v from the status line at the top", p.HeuristicBusy, tt.wantBusy)
+			}
+		})
+	}
+}
+
+func TestParseTmuxPanesShortLines(t *testing.T) {
+	out := strings.Join([]string{
+		"main:1.0\tclaude\t/src/app\t100\tapp\t111\t%1\t0\t0\t1700000000",
+		"main:2.0\tcodex\t\t200\tapi\t010\t%2\t0\t0\t1700000000", // empty path
+		"main:3.0\tgemini\t/src/web\t300\tweb",                   // older tmux: fields missing
+		"main:4.0\tkimi",                                         // only target and command
+		"",
+		"main:5.0", // no command
+		"\tclaude\t/src/app\t500",
+	}, "\n")
+	raw := parseTmuxPanes([]byte(out))
+	var got []string
+	for _, r := range raw {
+		got = append(got, fmt.Sprintf("%s %s/%s/%s %s %q %d", r.paneID, r.session, r.window, r.pane, r.cmd, r.path, r.pid))
+	}
+	want := []string{
+		`%1 main/1/0 claude "/src/app" 100`,
+		`%2 main/2/0 codex "" 200`,
+		`main:3.0 main/3/0 gemini "/src/web" 300`,
+		`main:4.0 main/4/0 kimi "" 0`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseTmuxPanes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(raw) > 0 && (!raw[0].windowFocused || raw[0].activity != 1700000000) {
+		t.Errorf("full line: focused = %v, activity = %d", raw[0].windowFocused, raw[0].activity)
+	}
+
+	// A padded line has no pid; its agent is kept on its command alone
+	// rather than judged by pid 0's children.
+	pt := provider.ParseProcessTable(`
+    1     0 init
+  100     1 claude
+  200     1 -zsh
+  201   200 codex
+  300     1 node /usr/local/lib/node_modules/@google/gemini-cli/dist/index.js
+`)
+	raw = append(raw, rawPane{target: "main:6.0", cmd: "zsh"}) // no pid, no agent
+	got = nil
+	for _, r := range resolveAgentPanes(raw, &pt) {
+		got = append(got, r.target+" "+r.cmd)
+	}
+	want = []string{"main:1.0 claude", "main:2.0 codex", "main:3.0 gemini", "main:4.0 kimi"}
+	if !slices.Equal(got, want) {
+		t.Errorf("resolveAgentPanes = %q, want %q", got, want)
+	}
+}
+
+func TestParseTmuxPanesCleansPath(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"/home/ana/app", "/home/ana/app"},
+		{"/home/ana/app/", "/home/ana/app"},
+		{"/home/ana//app/", "/home/ana/app"},
+		{"/", "/"},
+		{"//", "/"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		raw := parseTmuxPanes([]byte("main:1.0\tclaude\t" + tt.path + "\t100\tapp\t111\t%1\t0\t0\t0"))
+		if len(raw) != 1 || raw[0].path != tt.want {
+			t.Errorf("path %q parsed as %+v, want %q", tt.path, raw, tt.want)
+		}
+	}
+}
+
+func TestSwitchCommand(t *testing.T) {
+	tests := []struct {
+		target string
+		want   []string // tmux's arguments once the shell has parsed the command
+	}{
+		{"main:1.0", []string{"switch-client", "-t", "main:1", ";", "select-pane", "-t", "main:1.0"}},
+		{"ana's work:2.1", []string{"switch-client", "-t", "ana's work:2", ";", "select-pane", "-t", "ana's work:2.1"}},
+		{"x';touch /tmp/pwned;':3.0", []string{"switch-client", "-t", "x';touch /tmp/pwned;':3", ";", "select-pane", "-t", "x';touch /tmp/pwned;':3.0"}},
+	}
+	for _, tt := range tests {
+		cmd := SwitchCommand(tt.target)
+		// Stand in for tmux with a function printing one argument a line.
+		out, err := exec.Command("sh", "-c", `tmux() { printf '%s\n' "$@"; }; `+cmd).Output()
+		if err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+		if got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n"); !slices.Equal(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", cmd, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -42,6 +43,7 @@ func Watch(ctx context.Context) error {
 
 	const interval = 500 * time.Millisecond
 
+	var prevEvents []EventPane // for notifications on status transitions
 	for {
 		start := time.Now()
 
@@ -52,6 +54,9 @@ func Watch(ctx context.Context) error {
 
 		if panes, err := ListPanes(); err == nil {
 			r.Reconcile(panes)
+			if killed := autoKillIdle(panes, start); len(killed) > 0 {
+				panes = slices.DeleteFunc(panes, func(p Pane) bool { return killed[p.PaneID] })
+			}
 
 			// Re-read state to pick up stashed changes and any NEW overrides
 			// the TUI wrote while ListPanes was running. Only merge overrides
@@ -61,6 +66,7 @@ func Watch(ctx context.Context) error {
 			r.MergeNewOverrides(state, fresh)
 			state.LastPosition = fresh.LastPosition
 			state.SidebarWidth = fresh.SidebarWidth
+			state.WorkspaceOrder = fresh.WorkspaceOrder
 			stashed := make(map[string]bool, len(fresh.Panes))
 			for _, cp := range fresh.Panes {
 				if cp.Stashed {
@@ -68,6 +74,15 @@ func Watch(ctx context.Context) error {
 				}
 			}
 
+			next := make([]EventPane, len(panes))
+			for i, p := range panes {
+				next[i] = eventPane(p)
+			}
+			if prevEvents != nil {
+				notify(DiffPanes(prevEvents, next, start))
+			}
+			prevEvents = next
+
 			paneRefs := make([]*Pane, len(panes))
 			for i := range panes {
 				panes[i].Stashed = stashed[panes[i].PaneID]
@@ -134,7 +149,11 @@ func RestartWatch() error {
 	if err != nil {
 		return fmt.Errorf("restart watch: %w", err)
 	}
-	cmd := exec.Command(exe, "watch")
+	args := []string{"watch"}
+	if tmuxSocket != "" {
+		args = append(args, "--socket", tmuxSocket)
+	}
+	cmd := exec.Command(exe, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	return cmd.Start()
 }
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// NewWorktreeAgent creates a git worktree of repoPath on a new branch and
+// starts command in it, in a new tmux window. The worktree goes next to the
+// repository (not the subdirectory repoPath may be), named "<repo>-<branch>".
+// It returns the target of the new pane.
+func NewWorktreeAgent(repoPath, branch, command string) (string, error) {
+	branch = strings.TrimSpace(branch)
+	if branch == "" {
+		return "", fmt.Errorf("no branch name")
+	}
+	dir := worktreeDir(repoPath, branch)
+
+	cmd := exec.Command("git", "worktree", "add", "-b", branch, dir)
+	cmd.Dir = repoPath
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git worktree add: %s", gitError(out, err))
+	}
+
+	target, err := NewAgentPane("", dir, command)
+	if err != nil {
+		// Don't leave an unused worktree behind; the branch stays.
+		rm := exec.Command("git", "worktree", "remove", dir)
+		rm.Dir = repoPath
+		_ = rm.Run()
+		return "", err
+	}
+	return target, nil
+}
+
+// worktreeDir returns where NewWorktreeAgent puts the worktree of the
+// repository containing dir for branch.
+func worktreeDir(dir, branch string) string {
+	top := repoTop(dir)
+	return filepath.Join(filepath.Dir(top), filepath.Base(top)+"-"+strings.ReplaceAll(branch, "/", "-"))
+}
+
+// gitError returns the most useful line of a failed git command's output,
+// e.g. "fatal: a branch named 'x' already exists", or err when it printed
+// nothing.
+func gitError(out []byte, err error) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if l := strings.TrimSpace(lines[i]); strings.HasPrefix(l, "fatal:") || strings.HasPrefix(l, "error:") {
+			return l
+		}
+	}
+	if l := strings.TrimSpace(lines[len(lines)-1]); l != "" {
+		return l
+	}
+	return err.Error()
+}
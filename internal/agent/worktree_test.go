@@ -0,0 +1,16 @@
+package agent
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorktreeDir(t *testing.T) {
+	root := mkdirs(t, t.TempDir(), "mono/.git", "mono/services/api")
+	want := filepath.Join(root, "mono-feat-login")
+	for _, dir := range []string{"mono", "mono/services/api"} {
+		if got := worktreeDir(filepath.Join(root, dir), "feat/login"); got != want {
+			t.Errorf("worktreeDir(%s) = %s, want %s", dir, got, want)
+		}
+	}
+}
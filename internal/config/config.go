@@ -0,0 +1,345 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Config holds user settings read from config.json. Every field is optional;
+// the zero value means the built-in default.
+type Config struct {
+	ExcludeSessions []string          `json:"exclude_sessions,omitempty"` // session name globs
+	ExcludePaths    []string          `json:"exclude_paths,omitempty"`    // working directory globs
+	Snippets        map[string]string `json:"snippets,omitempty"`         // name -> text sent to a pane
+
+	// AltScreenFullCapture scans the whole visible screen, not just the last
+	// 10 lines, for panes on the alternate screen.
+	AltScreenFullCapture bool `json:"alt_screen_full_capture,omitempty"`
+
+	// TitleBadge appends the number of panes needing attention to the name
+	// of the tmux window agent-mux runs in, e.g. "agent-mux [2!]".
+	TitleBadge bool `json:"title_badge,omitempty"`
+
+	// ConfirmQuitWhenActive asks before quitting with q/esc while any agent
+	// is busy or needs attention.
+	ConfirmQuitWhenActive bool `json:"confirm_quit_when_active,omitempty"`
+
+	// KillChord is the key sequence that kills the selected pane: "dd" (the
+	// default), a single key like "x" or "delete", or a modified key like
+	// "ctrl+k". See ChordKeys.
+	KillChord string `json:"kill_chord,omitempty"`
+	// KillChordTimeoutMs is the longest pause allowed between the keys of a
+	// multi-key kill chord; 0 means no limit.
+	KillChordTimeoutMs int `json:"kill_chord_timeout_ms,omitempty"`
+
+	// AttentionDebounceTicks is how many consecutive refreshes the attention
+	// heuristic must match before a pane is marked as needing attention
+	// (default 1: immediately).
+	AttentionDebounceTicks int `json:"attention_debounce_ticks,omitempty"`
+
+	// ColorizeWorkspaces gives each workspace header a stable accent color
+	// hashed from its path.
+	ColorizeWorkspaces bool `json:"colorize_workspaces,omitempty"`
+
+	// SplitPreviewWidth is the terminal width from which the preview shows a
+	// second pane (the next one needing attention, else the next in the
+	// list) beside the selected one. 0 means 240 columns; -1 never splits.
+	SplitPreviewWidth int `json:"split_preview_width,omitempty"`
+
+	// ExpandOnAttention opens a collapsed workspace while one of its panes
+	// needs attention, and collapses it again afterwards: "expand", or
+	// "focus" to also move the cursor to the pane. Off when empty.
+	ExpandOnAttention string `json:"expand_on_attention,omitempty"`
+
+	// GroupBy picks what makes a workspace: "path" (the default), "repo" to
+	// merge subdirectories of one git repository, or "session" for the tmux
+	// session.
+	GroupBy string `json:"group_by,omitempty"`
+
+	// FlatList starts with panes listed without workspace headers; F toggles.
+	FlatList bool `json:"flat_list,omitempty"`
+
+	// ShowRecentProjects lists up to this many directories agents recently
+	// ran in, with none running there now, in a "recent" section; enter
+	// starts the agent there again, continuing its last conversation when
+	// the provider supports it.
+	ShowRecentProjects int `json:"show_recent_projects,omitempty"`
+
+	// MarkDuplicates flags panes running the same provider in the same
+	// directory as another pane, usually a session started twice by mistake.
+	MarkDuplicates bool `json:"mark_duplicates,omitempty"`
+
+	// PriorityPaths are workspace path globs (exclude_paths syntax) whose
+	// workspaces always sort above the rest.
+	PriorityPaths []string `json:"priority_paths,omitempty"`
+
+	// StatusPriority orders panes within a workspace by status, and picks
+	// where the cursor starts: e.g. ["busy", "attention", "idle"] to float
+	// working agents. It must name attention, busy and idle once each; unset
+	// keeps tmux order and starts on the first pane needing attention.
+	StatusPriority []string `json:"status_priority,omitempty"`
+
+	// StartupCursor picks the pane the cursor starts on: "attention" (the
+	// default), "busy", "recent" (the most recently active pane) or "top".
+	// When set it takes precedence over status_priority for the start.
+	StartupCursor string `json:"startup_cursor,omitempty"`
+
+	// ListOnRight puts the pane list on the right and the preview on the left.
+	ListOnRight bool `json:"list_on_right,omitempty"`
+
+	// TruncatePaths picks which end of a long path is cut: "" or "end" keeps
+	// the start ("~/src/proj…"), "start" keeps the end ("…/proj/worktree").
+	TruncatePaths string `json:"truncate_paths,omitempty"`
+
+	// ElapsedFormat picks how idle time is shown in pane rows: "" for a
+	// single unit ("3m", "2h"), or "seconds". ElapsedSuffix is appended to
+	// it, e.g. " ago".
+	ElapsedFormat string `json:"elapsed_format,omitempty"`
+	ElapsedSuffix string `json:"elapsed_suffix,omitempty"`
+
+	// AttentionPollMs is the pane refresh interval used while any pane needs
+	// attention (default 500ms; the normal interval is 2s).
+	AttentionPollMs int `json:"attention_poll_ms,omitempty"`
+
+	// AutoKillIdleAfter, when set (e.g. "6h"), makes the watch daemon kill
+	// agent panes with no new output for that long. Busy panes and panes
+	// needing attention are never killed.
+	AutoKillIdleAfter string `json:"auto_kill_idle_after,omitempty"`
+
+	// DetectContainer lists panes running docker/podman/devcontainer when
+	// their screen shows a known agent, for agents started inside a
+	// container where ps can't see them.
+	DetectContainer bool `json:"detect_container,omitempty"`
+
+	// Providers customizes how each provider's panes look, keyed by
+	// provider name.
+	Providers map[string]ProviderStyle `json:"providers,omitempty"`
+
+	// Notify runs commands on status transitions, per provider and event;
+	// see NotifyRule. Only the watch daemon acts on it.
+	Notify []NotifyRule `json:"notify,omitempty"`
+
+	CustomProviders []CustomProvider `json:"custom_providers,omitempty"`
+}
+
+// ProviderStyle overrides the look of one provider's panes.
+type ProviderStyle struct {
+	Icon  string `json:"icon,omitempty"`  // replaces the status glyph; its color still shows the status
+	Color string `json:"color,omitempty"` // window label color, e.g. "#D97706"
+}
+
+// NotifyRule runs Command when an agent raises Event: "done" (finished
+// working), "attention" (needs attention, e.g. a tool approval) or "auth"
+// (waiting for login). A rule naming a Provider takes precedence over one
+// without, which applies to every provider.
+type NotifyRule struct {
+	Provider string `json:"provider,omitempty"`
+	Event    string `json:"event"`
+	Command  string `json:"command"` // run with sh -c
+}
+
+// CustomProvider defines an agent CLI that has no built-in provider.
+type CustomProvider struct {
+	Name      string   `json:"name"`                 // command name, e.g. "qwen"
+	Busy      []string `json:"busy,omitempty"`       // phrases shown while working
+	ArgsToken string   `json:"args_token,omitempty"` // token identifying it in process args
+
+	// BusyScanLines widens the capture for agents whose busy indicator sits
+	// more than 10 lines above the bottom.
+	BusyScanLines int `json:"busy_scan_lines,omitempty"`
+
+	// BusyWindow narrows the busy check to the last N non-blank lines, for
+	// agents that leave stale spinner text higher up.
+	BusyWindow int `json:"busy_window,omitempty"`
+
+	// NoQuestionHeuristic stops conversational questions in the agent's
+	// output ("Would you like me to...") from flagging it as needing attention.
+	NoQuestionHeuristic bool `json:"no_question_heuristic,omitempty"`
+
+	// QuitKeys are the tmux keys X presses to make the agent exit before
+	// its pane is killed, e.g. ["C-c", "/exit", "Enter"] (default: ctrl+c
+	// twice).
+	QuitKeys []string `json:"quit_keys,omitempty"`
+}
+
+var (
+	loadOnce sync.Once
+	loaded   Config
+	loadErr  error
+)
+
+// Path returns the config file location, honoring XDG_CONFIG_HOME.
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "agent-mux", "config.json")
+}
+
+// Load reads the config file on first call and caches the result. A missing
+// file yields the defaults without error.
+func Load() (Config, error) {
+	loadOnce.Do(func() {
+		data, err := os.ReadFile(Path())
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			loadErr = fmt.Errorf("config: %w", err)
+			return
+		}
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			loaded = Config{}
+			loadErr = fmt.Errorf("config %s: %w", Path(), err)
+			return
+		}
+		// An invalid setting falls back to its default, with an error
+		// naming it rather than silently acting like the default.
+		var errs []error
+		if err := validStatusPriority(loaded.StatusPriority); err != nil {
+			loaded.StatusPriority = nil
+			errs = append(errs, err)
+		}
+		if err := validChoice("startup_cursor", loaded.StartupCursor, startupCursors); err != nil {
+			loaded.StartupCursor = ""
+			errs = append(errs, err)
+		}
+		if err := validChoice("group_by", loaded.GroupBy, groupings); err != nil {
+			loaded.GroupBy = ""
+			errs = append(errs, err)
+		}
+		if err := validChoice("truncate_paths", loaded.TruncatePaths, truncations); err != nil {
+			loaded.TruncatePaths = ""
+			errs = append(errs, err)
+		}
+		if _, err := ChordKeys(loaded.KillChord); err != nil {
+			loaded.KillChord = ""
+			errs = append(errs, err)
+		}
+		if err := errors.Join(errs...); err != nil {
+			loadErr = fmt.Errorf("config %s: %w", Path(), err)
+		}
+	})
+	return loaded, loadErr
+}
+
+// Statuses are the status classes status_priority orders.
+var Statuses = []string{"attention", "busy", "idle"}
+
+// validStatusPriority checks that order, when set, names each of Statuses
+// exactly once.
+func validStatusPriority(order []string) error {
+	if len(order) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(order))
+	for _, s := range order {
+		if !slices.Contains(Statuses, s) {
+			return fmt.Errorf("status_priority: unknown status %q (want %s)", s, strings.Join(Statuses, ", "))
+		}
+		if seen[s] {
+			return fmt.Errorf("status_priority: %q listed twice", s)
+		}
+		seen[s] = true
+	}
+	if len(seen) != len(Statuses) {
+		return fmt.Errorf("status_priority must list each of %s", strings.Join(Statuses, ", "))
+	}
+	return nil
+}
+
+// startupCursors are the values startup_cursor accepts.
+var startupCursors = []string{"attention", "busy", "recent", "top"}
+
+// groupings are the values group_by accepts.
+var groupings = []string{"path", "repo", "session"}
+
+// truncations are the values truncate_paths accepts.
+var truncations = []string{"end", "start"}
+
+// validChoice checks that value, when set, is one of choices for the setting
+// named key.
+func validChoice(key, value string, choices []string) error {
+	if value == "" || slices.Contains(choices, value) {
+		return nil
+	}
+	return fmt.Errorf("%s: unknown value %q (want %s)", key, value, strings.Join(choices, ", "))
+}
+
+// keyNames are the named keys a kill_chord may be, as the terminal reports
+// them; letters and other characters are given as themselves.
+var keyNames = []string{"enter", "tab", "esc", "backspace", "delete", "insert", "space",
+	"up", "down", "left", "right", "home", "end", "pgup", "pgdown",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12"}
+
+// ChordKeys splits a kill_chord spec into the keys pressed in turn: a named
+// key ("delete") or modified key ("ctrl+k") is one key, otherwise each of
+// one or two characters is ("x", "dd"). Empty means the default, "dd".
+func ChordKeys(spec string) ([]string, error) {
+	if spec == "" {
+		spec = "dd"
+	}
+	if mod, key, ok := strings.Cut(spec, "+"); ok && mod != "" && key != "" {
+		return []string{spec}, nil
+	}
+	if slices.Contains(keyNames, spec) {
+		return []string{spec}, nil
+	}
+	if n := utf8.RuneCountInString(spec); n > 2 || strings.ContainsFunc(spec, unicode.IsSpace) {
+		return nil, fmt.Errorf("kill_chord: %q is neither a key name nor one or two characters", spec)
+	}
+	var keys []string
+	for _, r := range spec {
+		keys = append(keys, string(r))
+	}
+	return keys, nil
+}
+
+// Set replaces the loaded config, as if it had been read from the file. It
+// lets tests exercise an option without a config file.
+func Set(cfg Config) {
+	loadOnce.Do(func() {})
+	loaded, loadErr = cfg, nil
+}
+
+// Get returns the loaded config. Errors are surfaced once by Load at startup;
+// callers deeper in the program just use whatever was loaded.
+func Get() Config {
+	cfg, _ := Load()
+	return cfg
+}
+
+// Matches reports whether s matches patterns, as used by exclude_sessions,
+// exclude_paths and priority_paths. Patterns use filepath.Match syntax with a
+// leading ~ expanded to the home directory. A pattern prefixed with "!"
+// re-includes what earlier patterns matched; the last matching pattern wins.
+func Matches(patterns []string, s string) bool {
+	matched := false
+	for _, pat := range patterns {
+		negate := strings.HasPrefix(pat, "!")
+		pat = expandHome(strings.TrimPrefix(pat, "!"))
+		if ok, _ := filepath.Match(pat, s); ok {
+			matched = !negate
+		}
+	}
+	return matched
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, _ := os.UserHomeDir()
+		return home + p[1:]
+	}
+	return p
+}
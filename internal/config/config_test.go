@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	t.Setenv("HOME", "/home/ana")
+	tests := []struct {
+		patterns []string
+		s        string
+		want     bool
+	}{
+		{[]string{"scratch"}, "scratch", true},
+		{[]string{"scratch"}, "scratchpad", false},
+		{[]string{"tmp-*"}, "tmp-1", true},
+		{[]string{"~/tmp/*"}, "/home/ana/tmp/play", true},
+		{[]string{"~/tmp/*"}, "/home/ana/tmp/play/sub", false}, // * stops at a separator
+		{[]string{"~"}, "/home/ana", true},
+		{[]string{"/src/*", "!/src/api"}, "/src/api", false},
+		{[]string{"/src/*", "!/src/api"}, "/src/web", true},
+		{[]string{"!/src/api", "/src/*"}, "/src/api", true}, // the last match wins
+		{[]string{"[bad"}, "[bad", false},                   // malformed patterns match nothing
+		{nil, "anything", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.patterns, tt.s); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.patterns, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestValidStatusPriority(t *testing.T) {
+	tests := []struct {
+		order []string
+		ok    bool
+	}{
+		{nil, true},
+		{[]string{"attention", "busy", "idle"}, true},
+		{[]string{"busy", "idle", "attention"}, true},
+		{[]string{"busy", "attention"}, false},                 // idle missing
+		{[]string{"busy", "busy", "idle"}, false},              // listed twice
+		{[]string{"attention", "busy", "idle", "done"}, false}, // unknown
+		{[]string{"Attention", "busy", "idle"}, false},         // case matters
+	}
+	for _, tt := range tests {
+		if err := validStatusPriority(tt.order); (err == nil) != tt.ok {
+			t.Errorf("validStatusPriority(%q) = %v, want ok %v", tt.order, err, tt.ok)
+		}
+	}
+}
+
+func TestValidChoice(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"", true},
+		{"busy", true},
+		{"top", true},
+		{"bussy", false},
+		{"Busy", false},
+	}
+	for _, tt := range tests {
+		if err := validChoice("startup_cursor", tt.value, startupCursors); (err == nil) != tt.ok {
+			t.Errorf("validChoice(%q) = %v, want ok %v", tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownChoices(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"startup_cursor": "bussy", "group_by": "repos", "truncate_paths": "left", "kill_chord": "ddd", "kill_chord_timeout_ms": 300}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loadOnce, loaded, loadErr = sync.Once{}, Config{}, nil
+	t.Cleanup(func() { loadOnce, loaded, loadErr = sync.Once{}, Config{}, nil })
+
+	cfg, err := Load()
+	for _, want := range []string{`startup_cursor: unknown value "bussy"`, `group_by: unknown value "repos"`, `truncate_paths: unknown value "left"`, `kill_chord: "ddd"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load error = %v, want one containing %s", err, want)
+		}
+	}
+	if cfg.StartupCursor != "" || cfg.GroupBy != "" || cfg.TruncatePaths != "" || cfg.KillChord != "" || cfg.KillChordTimeoutMs != 300 {
+		t.Errorf("Load = %+v, want the invalid settings reset and the rest kept", cfg)
+	}
+}
+
+func TestChordKeys(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string // nil when the spec is rejected
+	}{
+		{"", []string{"d", "d"}},
+		{"dd", []string{"d", "d"}},
+		{"x", []string{"x"}},
+		{"éé", []string{"é", "é"}},
+		{"ctrl+k", []string{"ctrl+k"}},
+		{"shift+tab", []string{"shift+tab"}},
+		{"delete", []string{"delete"}},
+		{"f5", []string{"f5"}},
+		{"+", []string{"+"}},
+		{"ddd", nil},
+		{"kill", nil},
+		{"ctrl+", nil},
+		{"d d", nil},
+	}
+	for _, tt := range tests {
+		got, err := ChordKeys(tt.spec)
+		if !slices.Equal(got, tt.want) || (err != nil) != (tt.want == nil) {
+			t.Errorf("ChordKeys(%q) = %q, %v; want %q", tt.spec, got, err, tt.want)
+		}
+	}
+}
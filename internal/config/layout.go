@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Layout is a set of agents to start together with --launch, read from a
+// JSON file:
+//
+//	{
+//	  "session": "work",
+//	  "agents": [
+//	    {"dir": "~/src/api", "provider": "claude"},
+//	    {"dir": "~/src/web", "provider": "codex", "command": "codex --model o3"}
+//	  ]
+//	}
+type Layout struct {
+	Session string        `json:"session,omitempty"` // tmux session for the new windows; default: the current one
+	Agents  []LayoutAgent `json:"agents"`
+}
+
+// LayoutAgent is one agent of a Layout.
+type LayoutAgent struct {
+	Dir      string `json:"dir"`               // working directory; ~ expands to $HOME
+	Provider string `json:"provider"`          // e.g. "claude"; run as the command unless Command is set
+	Command  string `json:"command,omitempty"` // full command line, e.g. "claude --model opus"
+}
+
+// LoadLayout reads and checks the layout file at path. Dirs come back with ~
+// expanded and every agent has a Command.
+func LoadLayout(path string) (Layout, error) {
+	var l Layout
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return l, fmt.Errorf("layout: %w", err)
+	}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return l, fmt.Errorf("layout %s: %w", path, err)
+	}
+	if len(l.Agents) == 0 {
+		return l, fmt.Errorf("layout %s: no agents", path)
+	}
+	for i, a := range l.Agents {
+		if a.Dir == "" {
+			return l, fmt.Errorf("layout %s: agent %d has no dir", path, i+1)
+		}
+		if a.Command == "" {
+			a.Command = a.Provider
+		}
+		if a.Command == "" {
+			return l, fmt.Errorf("layout %s: agent %d (%s) has no provider or command", path, i+1, a.Dir)
+		}
+		a.Dir = expandHome(a.Dir)
+		l.Agents[i] = a
+	}
+	return l, nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadLayout(t *testing.T) {
+	t.Setenv("HOME", "/home/ana")
+	tests := []struct {
+		name, file string
+		want       Layout
+		err        string // substring of the error; "" when it loads
+	}{
+		{"provider as the command", `{"session": "work", "agents": [
+			{"dir": "~/src/api", "provider": "claude"},
+			{"dir": "/srv/web", "provider": "codex", "command": "codex --model o3"}
+		]}`, Layout{Session: "work", Agents: []LayoutAgent{
+			{Dir: "/home/ana/src/api", Provider: "claude", Command: "claude"},
+			{Dir: "/srv/web", Provider: "codex", Command: "codex --model o3"},
+		}}, ""},
+		{"command without a provider", `{"agents": [{"dir": "~", "command": "aider --yes"}]}`,
+			Layout{Agents: []LayoutAgent{{Dir: "/home/ana", Command: "aider --yes"}}}, ""},
+		{"no agents", `{"session": "work", "agents": []}`, Layout{}, "no agents"},
+		{"agent without a dir", `{"agents": [{"dir": "/srv/web", "provider": "codex"}, {"provider": "claude"}]}`,
+			Layout{}, "agent 2 has no dir"},
+		{"agent without anything to run", `{"agents": [{"dir": "/srv/web"}]}`,
+			Layout{}, "agent 1 (/srv/web) has no provider or command"},
+		{"not JSON", `session = "work"`, Layout{}, "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "layout.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadLayout(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadLayout =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadLayout(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("a missing layout file loaded")
+	}
+}
@@ -0,0 +1,205 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+)
+
+// label maps a phrase seen in captured output to a descriptive status, for
+// a pane in the status it describes.
+type label struct {
+	match  string
+	text   string
+	status Status
+}
+
+// cli is a provider defined by its name and the phrases its UI prints. Labels
+// are checked bottom-up, so the most recent line wins.
+type cli struct {
+	name        string
+	labels      []label
+	busy        []string       // phrases shown only while working
+	auth        []string       // login / API key prompts
+	attention   []string       // prompts waiting on the user the generic heuristic misses
+	input       []string       // markers starting the input line, e.g. "❯"
+	resume      string         // args continuing the last conversation, e.g. "--continue"
+	quit        []string       // keys that exit cleanly (see Quitter)
+	done        *regexp.Regexp // completion summary printed when a task finishes
+	lowContext  []string       // warnings that the context window is nearly full
+	model       []string       // flags that take the model name, e.g. "--model"
+	session     []string       // args that take a session id, e.g. "--resume"
+	sessionRe   *regexp.Regexp // session id printed in the UI; first group is the id
+	scan        int            // trailing lines to capture for busy detection; 0 = default
+	window      int            // trailing lines the busy check looks at; 0 = the whole capture
+	noQuestions bool           // asks rhetorical questions; skip the question heuristic
+}
+
+func (c cli) Name() string { return c.name }
+
+func (c cli) IsBusy(lines []string) bool {
+	for _, l := range lines {
+		for _, b := range c.busy {
+			if strings.Contains(l, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c cli) AuthPatterns() []string { return c.auth }
+
+func (c cli) AttentionPatterns() []string { return c.attention }
+
+func (c cli) PromptMarkers() []string { return c.input }
+
+func (c cli) ResumeArgs() string { return c.resume }
+
+func (c cli) QuitKeys() []string { return c.quit }
+
+func (c cli) BusyScanLines() int { return c.scan }
+
+func (c cli) BusyWindow() int { return c.window }
+
+func (c cli) UsesQuestionHeuristic() bool { return !c.noQuestions }
+
+func (c cli) LowContext(lines []string) bool {
+	for _, l := range lines {
+		for _, w := range c.lowContext {
+			if strings.Contains(l, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c cli) JustCompleted(lines []string) bool {
+	if c.done == nil {
+		return false
+	}
+	for _, l := range lines {
+		if c.done.MatchString(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelFromArgs returns the value of the first of c.model's flags in args,
+// given either as "--model opus" or "--model=opus".
+func (c cli) ModelFromArgs(args string) string {
+	return flagValue(args, c.model)
+}
+
+// SessionID returns the session id the agent was resumed with, or failing
+// that the one its UI shows.
+func (c cli) SessionID(lines []string, args string) string {
+	// "--resume" alone opens a picker; don't take the next flag for an id.
+	if id := flagValue(args, c.session); id != "" && !strings.HasPrefix(id, "-") {
+		return id
+	}
+	if c.sessionRe == nil {
+		return ""
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if m := c.sessionRe.FindStringSubmatch(lines[i]); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// flagValue returns the value of the first of flags found in args, given
+// either as "--flag value" or "--flag=value".
+func flagValue(args string, flags []string) string {
+	fields := strings.Fields(args)
+	for i, f := range fields {
+		for _, flag := range flags {
+			if v, ok := strings.CutPrefix(f, flag+"="); ok {
+				return v
+			}
+			if f == flag && i+1 < len(fields) {
+				return fields[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+func (c cli) StatusLabel(lines []string, status Status) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		for _, l := range c.labels {
+			if l.status == status && strings.Contains(lines[i], l.match) {
+				return l.text
+			}
+		}
+	}
+	return ""
+}
+
+// screenMatch scores the best of c's status labels, completion summary and
+// busy phrases found in lines.
+func (c cli) screenMatch(lines []string) screenScore {
+	var best screenScore
+	consider := func(kind int, text string) {
+		if s := (screenScore{kind, len(text)}); best.less(s) {
+			best = s
+		}
+	}
+	for _, line := range lines {
+		for _, l := range c.labels {
+			if strings.Contains(line, l.match) {
+				consider(3, l.match)
+			}
+		}
+		if c.done != nil {
+			if m := c.done.FindString(line); m != "" {
+				consider(2, m)
+			}
+		}
+		for _, b := range c.busy {
+			if strings.Contains(line, b) {
+				consider(1, b)
+			}
+		}
+	}
+	return best
+}
+
+var builtins = []Provider{
+	cli{name: "claude", labels: []label{
+		{"Do you want to proceed?", "awaiting approval", StatusAttention},
+		{"Do you want to make this edit", "awaiting approval", StatusAttention},
+		{"Compacting conversation", "compacting", StatusBusy},
+		{"esc to interrupt", "generating", StatusBusy},
+	}, busy: []string{"esc to interrupt"}, auth: []string{"Select login method", "Run /login"},
+		done: regexp.MustCompile(`^✻ \p{L}+ for \d+[hms]`), model: []string{"--model"},
+		lowContext: []string{"Context left until auto-compact", "Context low ("},
+		session:    []string{"--session-id", "--resume", "-r"}, input: []string{"❯", "│ >"},
+		resume: "--continue", quit: []string{"C-c", "/exit", "Enter"}},
+	cli{name: "codex", labels: []label{
+		{"Allow command?", "awaiting approval", StatusAttention},
+		{"Esc to interrupt", "working", StatusBusy},
+		{"esc to interrupt", "working", StatusBusy},
+	}, auth: []string{"Sign in with ChatGPT", "Provide your own API key"},
+		done: regexp.MustCompile(`Worked for \d+[hms]`), model: []string{"--model", "-m"}, input: []string{"›"},
+		session: []string{"resume"}, resume: "resume --last", quit: []string{"C-c", "/quit", "Enter"}, sessionRe: regexp.MustCompile(`(?i)\bsession(?: id)?:\s+([0-9a-f]{8}-[0-9a-f-]{27})`),
+		noQuestions: true},
+	cli{name: "gemini", labels: []label{
+		{"Allow execution", "awaiting approval", StatusAttention},
+		{"Apply this change?", "awaiting approval", StatusAttention},
+		{"esc to cancel", "generating", StatusBusy},
+	}, auth: []string{"Login with Google", "Waiting for auth"}, model: []string{"--model", "-m"},
+		input: []string{"│ >"}, quit: []string{"C-c", "/quit", "Enter"}, noQuestions: true},
+	cli{name: "opencode", labels: []label{
+		{"Permission required", "awaiting approval", StatusAttention},
+		{"esc interrupt", "working", StatusBusy},
+	}, attention: []string{"Permission required", "Allow always"},
+		model: []string{"--model", "-m"}, session: []string{"--session", "-s"}, resume: "--continue", noQuestions: true},
+	cli{name: "kimi", labels: []label{
+		{"esc to interrupt", "generating", StatusBusy},
+	}, model: []string{"--model", "-m"}, noQuestions: true},
+	cli{name: "smelt"},
+	cli{name: "ralph"},
+}
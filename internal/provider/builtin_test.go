@@ -0,0 +1,47 @@
+package provider
+
+import "testing"
+
+func TestModelFromArgs(t *testing.T) {
+	tests := []struct {
+		name, args, want string
+	}{
+		{"claude", "claude --model opus", "opus"},
+		{"claude", "node /usr/lib/node_modules/@anthropic-ai/claude-code/cli.js --model=sonnet --continue", "sonnet"},
+		{"claude", "claude --continue", ""},
+		{"claude", "claude --model", ""},
+		{"codex", "codex -m o3 --full-auto", "o3"},
+		{"codex", "codex --model=gpt-5-codex", "gpt-5-codex"},
+		{"gemini", "node /usr/bin/gemini -m gemini-2.5-pro", "gemini-2.5-pro"},
+		{"opencode", "opencode --model anthropic/claude-sonnet-4", "anthropic/claude-sonnet-4"},
+		{"smelt", "smelt --model big", ""},
+		{"unknown", "unknown --model big", ""},
+	}
+	for _, tt := range tests {
+		if got := ModelFromArgs(tt.name, tt.args); got != tt.want {
+			t.Errorf("ModelFromArgs(%q, %q) = %q, want %q", tt.name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestStatusLabel(t *testing.T) {
+	lines := []string{
+		"⏺ Bash(go test ./...)",
+		"Do you want to proceed?",
+		"✻ Running… (12s · esc to interrupt)",
+	}
+	tests := []struct {
+		status Status
+		want   string
+	}{
+		{StatusBusy, "generating"},
+		{StatusAttention, "awaiting approval"},
+		{StatusIdle, ""}, // the spinner text left on screen isn't a label for an idle pane
+		{StatusAuth, ""},
+	}
+	for _, tt := range tests {
+		if got := StatusLabel("claude", lines, tt.status); got != tt.want {
+			t.Errorf("StatusLabel(claude, %v) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package provider
 
 import (
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // ProcessTable holds a snapshot of the system process tree.
@@ -12,19 +15,191 @@ type ProcessTable struct {
 	Args     map[int]string // pid -> full command line
 }
 
-var registry = map[string]bool{}
+// Provider describes an AI coding agent CLI. Only Name is required; optional
+// capabilities are expressed as separate interfaces that a provider may also
+// implement (see StatusLabeler).
+type Provider interface {
+	// Name returns the normalized command name, e.g. "claude".
+	Name() string
+}
+
+// Status is what agent-mux concluded a pane is doing, as given to
+// StatusLabeler. It mirrors the agent package's statuses that a label can
+// describe; any other (unread, say) is StatusIdle.
+type Status int
+
+const (
+	StatusIdle Status = iota
+	StatusBusy
+	StatusAttention
+	StatusAuth
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusBusy:
+		return "busy"
+	case StatusAttention:
+		return "needs attention"
+	case StatusAuth:
+		return "needs login"
+	default:
+		return "idle"
+	}
+}
+
+// StatusLabeler is implemented by providers that can describe what the agent
+// is doing from its captured output, e.g. "generating" while busy or
+// "awaiting approval" while it needs attention. StatusLabel returns "" when
+// nothing specific to status is recognized.
+type StatusLabeler interface {
+	StatusLabel(lines []string, status Status) string
+}
+
+// BusyDetector is implemented by providers whose UI shows a recognizable
+// indicator while the agent is working.
+type BusyDetector interface {
+	IsBusy(lines []string) bool
+}
+
+// AuthPrompter is implemented by providers with login or API key prompts
+// beyond the generic ones agent-mux already recognizes.
+type AuthPrompter interface {
+	AuthPatterns() []string
+}
+
+// AttentionPrompter is implemented by providers whose confirmation or
+// selection prompts the generic attention heuristic doesn't recognize.
+type AttentionPrompter interface {
+	AttentionPatterns() []string
+}
+
+// PromptMarker is implemented by providers whose input line starts with a
+// recognizable marker, e.g. "❯". Text the user is typing there is not the
+// agent asking anything, so attention detection skips those lines.
+type PromptMarker interface {
+	PromptMarkers() []string
+}
+
+// BusyScanner is implemented by providers whose busy indicator can sit well
+// above the bottom of the screen (e.g. a status bar with the input box below
+// it). BusyScanLines is how many trailing lines must be captured to see it.
+type BusyScanner interface {
+	BusyScanLines() int
+}
+
+// BusyWindower is implemented by providers whose busy indicator is always on
+// the last few lines. BusyWindow is how many trailing non-blank lines the busy
+// check looks at, so spinner text left higher up the screen doesn't read as
+// busy; attention detection still scans the whole capture.
+type BusyWindower interface {
+	BusyWindow() int
+}
+
+// QuestionHeuristic is implemented by providers that may opt out of treating
+// conversational questions ("Would you like me to...") as a request for
+// input, for agents that ask them without waiting.
+type QuestionHeuristic interface {
+	UsesQuestionHeuristic() bool
+}
+
+// CompletionDetector is implemented by providers that print a recognizable
+// summary when they finish a task, e.g. "Worked for 2m 13s".
+type CompletionDetector interface {
+	JustCompleted(lines []string) bool
+}
+
+// ContextWarner is implemented by providers that warn when the conversation
+// is about to outgrow the context window, e.g. before auto-compacting.
+type ContextWarner interface {
+	LowContext(lines []string) bool
+}
+
+// SessionIdentifier is implemented by providers that can tell which
+// session/conversation an agent is in, from its UI or its command line.
+type SessionIdentifier interface {
+	SessionID(lines []string, args string) string
+}
+
+// Resumer is implemented by providers that can pick up their most recent
+// conversation in a directory. ResumeArgs are the arguments that do it, e.g.
+// "--continue".
+type Resumer interface {
+	ResumeArgs() string
+}
+
+// Quitter is implemented by providers with their own way to exit cleanly.
+// QuitKeys are tmux send-keys arguments pressed in order: key names such as
+// "C-c" or "Enter", anything else typed as text, e.g. "/exit".
+type Quitter interface {
+	QuitKeys() []string
+}
+
+// ModelParser is implemented by providers that can read the model an agent
+// was launched with from its command line, e.g. "--model opus".
+type ModelParser interface {
+	ModelFromArgs(args string) string
+}
+
+var registry = map[string]Provider{}
+
+// aliases maps extra process-args tokens to a registered provider name.
+var aliases = map[string]string{}
 
 func init() {
-	for _, cmd := range []string{"smelt", "claude", "codex", "gemini", "opencode", "ralph", "kimi"} {
-		Register(cmd)
+	for _, p := range builtins {
+		Register(p)
 	}
 }
 
-// Register adds an agent command name to the global registry.
-func Register(cmd string) {
-	normalized := normalize(cmd)
+// Register adds a provider to the global registry, replacing any provider
+// previously registered under the same name.
+func Register(p Provider) {
+	normalized := normalize(p.Name())
 	if normalized != "" {
-		registry[normalized] = true
+		registry[normalized] = p
+	}
+}
+
+// Lookup returns the registered provider with the given name, or nil.
+func Lookup(name string) Provider {
+	return registry[normalize(name)]
+}
+
+// All returns the names of every registered provider, sorted.
+func All() []string {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// Spec describes a provider defined in user configuration rather than code.
+type Spec struct {
+	Name           string   // command name, e.g. "qwen"
+	BusyIndicators []string // phrases shown while the agent is working
+	ArgsToken      string   // optional token identifying the agent in process args
+	BusyScanLines  int      // trailing lines to capture for busy detection; 0 = default
+	BusyWindow     int      // trailing lines the busy check looks at; 0 = the whole capture
+	NoQuestions    bool     // don't treat conversational questions as needing attention
+	QuitKeys       []string // keys that make the agent exit cleanly; empty = ctrl+c twice
+}
+
+// RegisterCustom registers a provider built from spec.
+func RegisterCustom(spec Spec) {
+	Register(cli{name: spec.Name, busy: spec.BusyIndicators, scan: spec.BusyScanLines, window: spec.BusyWindow,
+		noQuestions: spec.NoQuestions, quit: spec.QuitKeys})
+	if token := normalize(spec.ArgsToken); token != "" {
+		aliases[token] = normalize(spec.Name)
+	}
+}
+
+// Unregister removes the named provider and the process-args tokens that
+// resolve to it.
+func Unregister(name string) {
+	name = normalize(name)
+	delete(registry, name)
+	for token, target := range aliases {
+		if target == name {
+			delete(aliases, token)
+		}
 	}
 }
 
@@ -33,32 +208,234 @@ func IsAgent(cmd string) bool {
 	return resolveRegistered(cmd) != ""
 }
 
+// IsBusy reports whether the named provider recognizes a busy indicator in
+// lines. Providers without a BusyDetector never report busy.
+func IsBusy(name string, lines []string) bool {
+	if d, ok := Lookup(name).(BusyDetector); ok {
+		return d.IsBusy(lines)
+	}
+	return false
+}
+
+// BusyScanLines returns how many trailing lines the named provider needs
+// captured for busy detection, or 0 for the default.
+func BusyScanLines(name string) int {
+	if s, ok := Lookup(name).(BusyScanner); ok {
+		return s.BusyScanLines()
+	}
+	return 0
+}
+
+// BusyWindow returns how many trailing non-blank lines the named provider's
+// busy check looks at, or 0 for the whole capture.
+func BusyWindow(name string) int {
+	if w, ok := Lookup(name).(BusyWindower); ok {
+		return w.BusyWindow()
+	}
+	return 0
+}
+
+// UsesQuestionHeuristic reports whether conversational questions mark the
+// named provider's pane as needing attention. Defaults to true.
+func UsesQuestionHeuristic(name string) bool {
+	if q, ok := Lookup(name).(QuestionHeuristic); ok {
+		return q.UsesQuestionHeuristic()
+	}
+	return true
+}
+
+// NeedsAuth reports whether lines contain one of the named provider's login
+// prompts.
+func NeedsAuth(name string, lines []string) bool {
+	a, ok := Lookup(name).(AuthPrompter)
+	if !ok {
+		return false
+	}
+	for _, l := range lines {
+		for _, pat := range a.AuthPatterns() {
+			if strings.Contains(l, pat) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsInputLine reports whether line, a normalized screen line, is the named
+// provider's input line: it starts with one of the provider's prompt markers.
+func IsInputLine(name, line string) bool {
+	m, ok := Lookup(name).(PromptMarker)
+	if !ok {
+		return false
+	}
+	for _, marker := range m.PromptMarkers() {
+		if strings.HasPrefix(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsAttention reports whether lines contain one of the named provider's
+// own confirmation or selection prompts.
+func NeedsAttention(name string, lines []string) bool {
+	a, ok := Lookup(name).(AttentionPrompter)
+	if !ok {
+		return false
+	}
+	for _, l := range lines {
+		for _, pat := range a.AttentionPatterns() {
+			if strings.Contains(l, pat) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// JustCompleted reports whether lines show the named provider's completion
+// summary.
+func JustCompleted(name string, lines []string) bool {
+	if d, ok := Lookup(name).(CompletionDetector); ok {
+		return d.JustCompleted(lines)
+	}
+	return false
+}
+
+// LowContext reports whether lines show the named provider's low-context
+// warning.
+func LowContext(name string, lines []string) bool {
+	if w, ok := Lookup(name).(ContextWarner); ok {
+		return w.LowContext(lines)
+	}
+	return false
+}
+
+// SessionID returns the named provider's session id for an agent with the
+// given screen lines and command line, or "" when unknown.
+func SessionID(name string, lines []string, args string) string {
+	if s, ok := Lookup(name).(SessionIdentifier); ok {
+		return s.SessionID(lines, args)
+	}
+	return ""
+}
+
+// ResumeCommand returns the command line that starts the named provider,
+// continuing its last conversation in the working directory when the
+// provider supports it.
+func ResumeCommand(name string) string {
+	if r, ok := Lookup(name).(Resumer); ok && r.ResumeArgs() != "" {
+		return name + " " + r.ResumeArgs()
+	}
+	return name
+}
+
+// defaultQuitKeys interrupt and exit most agent CLIs: the first ctrl+c
+// cancels what is running or typed, the second quits.
+var defaultQuitKeys = []string{"C-c", "C-c"}
+
+// QuitKeys returns the keys that make the named provider exit cleanly.
+func QuitKeys(name string) []string {
+	if q, ok := Lookup(name).(Quitter); ok && len(q.QuitKeys()) > 0 {
+		return q.QuitKeys()
+	}
+	return defaultQuitKeys
+}
+
+// ModelFromArgs returns the model named in an agent's command line, or "".
+func ModelFromArgs(name, args string) string {
+	if p, ok := Lookup(name).(ModelParser); ok {
+		return p.ModelFromArgs(args)
+	}
+	return ""
+}
+
+// StatusLabel returns the descriptive label the named provider derives from
+// lines for a pane in status, or "" if the provider has none.
+func StatusLabel(name string, lines []string, status Status) string {
+	if l, ok := Lookup(name).(StatusLabeler); ok {
+		return l.StatusLabel(lines, status)
+	}
+	return ""
+}
+
+// screenMatcher is implemented by providers that can tell how strongly
+// lines show their UI; see FromScreen.
+type screenMatcher interface {
+	screenMatch(lines []string) screenScore
+}
+
+// screenScore ranks how specifically lines identify a provider: a status
+// label over a completion summary over a busy phrase, then the longer
+// matched text. The zero score is no match.
+type screenScore struct {
+	kind   int // 0 none, 1 busy phrase, 2 completion summary, 3 status label
+	length int
+}
+
+func (s screenScore) less(o screenScore) bool {
+	return s.kind < o.kind || (s.kind == o.kind && s.length < o.length)
+}
+
+// FromScreen returns the provider whose UI lines show most specifically (see
+// screenScore), or "" when none matches or the best match is shared, e.g.
+// "esc to interrupt" alone. It identifies agents whose process can't be
+// seen, e.g. inside a container.
+func FromScreen(lines []string) string {
+	var best screenScore
+	var name string
+	tied := false
+	for _, n := range All() {
+		m, ok := Lookup(n).(screenMatcher)
+		if !ok {
+			continue
+		}
+		score := m.screenMatch(lines)
+		switch {
+		case score.kind == 0 || score.less(best):
+		case best.less(score):
+			best, name, tied = score, n, false
+		default:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return name
+}
+
 // Resolve returns the provider command name for a tmux pane. It first checks
 // the direct command, then falls back to inspecting children of the shell
-// process via the process table (handles cases like gemini running as "node").
+// process via the process table. Matching each of the children's args, in
+// order, handles agents hosted by a runtime: gemini running as
+// "node", or opencode running as "node"/"bun" with an entrypoint such as
+// .../opencode-ai/bin/opencode, as well as its native binary.
 func Resolve(cmd string, shellPID int, pt *ProcessTable) string {
+	name, _ := ResolvePID(cmd, shellPID, pt)
+	return name
+}
+
+// ResolvePID is Resolve that also returns the pid of the agent process: the
+// pane's own process when cmd matched directly, otherwise the child that did.
+func ResolvePID(cmd string, shellPID int, pt *ProcessTable) (string, int) {
 	if matched := resolveRegistered(cmd); matched != "" {
-		return matched
+		return matched, shellPID
 	}
 	for _, childPID := range pt.Children[shellPID] {
 		comm := pt.Comm[childPID]
 		if matched := resolveRegistered(comm); matched != "" {
-			return matched
-		}
-		args := pt.Args[childPID]
-		if matched := resolveRegistered(args); matched != "" {
-			return matched
+			return matched, childPID
 		}
-		for arg := range strings.SplitSeq(args, " ") {
-			if idx := strings.LastIndex(arg, "/"); idx >= 0 {
-				arg = arg[idx+1:]
-			}
+		// The first argument naming an agent is the entrypoint; later ones
+		// are its options, e.g. opencode's "--model anthropic/claude-sonnet-4".
+		for arg := range strings.FieldsSeq(pt.Args[childPID]) {
 			if matched := resolveRegistered(arg); matched != "" {
-				return matched
+				return matched, childPID
 			}
 		}
 	}
-	return ""
+	return "", 0
 }
 
 func normalize(cmd string) string {
@@ -70,48 +447,72 @@ func resolveRegistered(cmd string) string {
 	if normalized == "" {
 		return ""
 	}
+	if matched := matchRegistered(normalized); matched != "" {
+		return matched
+	}
+	if idx := strings.LastIndex(normalized, "/"); idx >= 0 {
+		return matchRegistered(normalized[idx+1:])
+	}
+	return ""
+}
+
+// matchRegistered returns the provider whose name or alias token occurs in s.
+func matchRegistered(s string) string {
 	for registered := range registry {
-		if strings.Contains(normalized, registered) {
+		if strings.Contains(s, registered) {
 			return registered
 		}
 	}
-	if idx := strings.LastIndex(normalized, "/"); idx >= 0 {
-		base := normalized[idx+1:]
-		for registered := range registry {
-			if strings.Contains(base, registered) {
-				return registered
-			}
+	for token, name := range aliases {
+		if strings.Contains(s, token) {
+			return name
 		}
 	}
 	return ""
 }
 
 // ParseProcessTable builds a ProcessTable from raw `ps -eo pid=,ppid=,command=` output.
+// It scans each line in place rather than splitting into fields, since on busy
+// hosts the table can run to thousands of lines and is rebuilt every tick.
+// Invalid UTF-8 in command lines is replaced with U+FFFD.
 func ParseProcessTable(out string) ProcessTable {
+	out = strings.ToValidUTF8(out, "\uFFFD")
+	n := strings.Count(out, "\n") + 1
 	pt := ProcessTable{
-		Children: make(map[int][]int),
-		Comm:     make(map[int]string),
-		Args:     make(map[int]string),
+		Children: make(map[int][]int, n),
+		Comm:     make(map[int]string, n),
+		Args:     make(map[int]string, n),
 	}
 	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
+		pidStr, rest := nextField(line)
+		ppidStr, cmdline := nextField(rest)
+		comm, _ := nextField(cmdline)
+		if comm == "" {
 			continue
 		}
-		pid, err1 := strconv.Atoi(fields[0])
-		ppid, err2 := strconv.Atoi(fields[1])
+		pid, err1 := strconv.Atoi(pidStr)
+		ppid, err2 := strconv.Atoi(ppidStr)
 		if err1 != nil || err2 != nil {
 			continue
 		}
-		cmdline := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
-		cmdline = strings.TrimSpace(strings.TrimPrefix(cmdline, fields[1]))
 		pt.Children[ppid] = append(pt.Children[ppid], pid)
-		pt.Args[pid] = cmdline
-		pt.Comm[pid] = fields[2]
+		pt.Args[pid] = strings.TrimSpace(cmdline)
+		pt.Comm[pid] = comm
 	}
 	return pt
 }
+
+// nextField returns the first whitespace-delimited field of s and the
+// remainder after it, without allocating.
+func nextField(s string) (field, rest string) {
+	start := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+	if start < 0 {
+		return "", ""
+	}
+	s = s[start:]
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
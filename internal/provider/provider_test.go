@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFromScreen(t *testing.T) {
+	tests := []struct {
+		name, frame, want string
+	}{
+		{"claude completion", `
+⏺ Updated the handler and its tests.
+
+✻ Worked for 2m 13s
+`, "claude"},
+		{"claude approval", `
+ Bash command
+   rm -rf build/
+ Do you want to proceed?
+ ❯ 1. Yes
+`, "claude"},
+		{"codex working", `
+• Running go test ./...
+
+• Working (12s • Esc to interrupt)
+`, "codex"},
+		{"codex completion", `
+─ Worked for 1m 02s ─────────────────
+`, "codex"},
+		{"gemini", `
+⠼ Thinking about the build (esc to cancel, 4s)
+`, "gemini"},
+		{"opencode", `
+  Build  claude-sonnet-4
+  working...  esc interrupt
+`, "opencode"},
+		{"shared busy phrase", `
+✢ Reading files… (esc to interrupt)
+`, ""},
+		{"plain shell", `
+root@3f2a1c:/workspace# ls
+go.mod  main.go
+root@3f2a1c:/workspace#
+`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(strings.Trim(tt.frame, "\n"), "\n")
+			if got := FromScreen(lines); got != tt.want {
+				t.Errorf("FromScreen = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProcessTable(t *testing.T) {
+	pt := ParseProcessTable(`
+    1     0 /sbin/init
+  100     1 -zsh
+  101   100 node   /usr/local/bin/gemini  --model pro
+  102   100 claude
+garbage line
+  103
+  abc   100 sleep 1
+`)
+	if got := pt.Children[100]; !slices.Equal(got, []int{101, 102}) {
+		t.Errorf("Children[100] = %v, want [101 102]", got)
+	}
+	if got := pt.Comm[101]; got != "node" {
+		t.Errorf("Comm[101] = %q, want node", got)
+	}
+	if got, want := pt.Args[101], "node   /usr/local/bin/gemini  --model pro"; got != want {
+		t.Errorf("Args[101] = %q, want %q", got, want)
+	}
+	if got := pt.Args[1]; got != "/sbin/init" {
+		t.Errorf("Args[1] = %q, want /sbin/init", got)
+	}
+	if len(pt.Comm) != 4 {
+		t.Errorf("parsed %d processes, want 4 (malformed lines skipped): %v", len(pt.Comm), pt.Comm)
+	}
+}
+
+// BenchmarkParseProcessTable parses a synthetic ps listing of a busy host:
+// 5000 processes, mostly node workers with long argument lists.
+func BenchmarkParseProcessTable(b *testing.B) {
+	var sb strings.Builder
+	for i := range 5000 {
+		fmt.Fprintf(&sb, "%6d %6d /usr/local/bin/node /opt/app/node_modules/.bin/worker --id %d --verbose\n", i+2, i/4+1, i)
+	}
+	out := sb.String()
+	b.ReportAllocs()
+	for b.Loop() {
+		ParseProcessTable(out)
+	}
+}
+
+func TestParseProcessTableInvalidUTF8(t *testing.T) {
+	pt := ParseProcessTable("100 1 -bash\n101 100 claude --append-system-prompt \xff\xfe\xc3\x28\n")
+	if got := pt.Args[101]; !utf8.ValidString(got) || !strings.HasPrefix(got, "claude --append-system-prompt ") {
+		t.Errorf("Args[101] = %q, want the args with invalid bytes replaced", got)
+	}
+	if pt.Comm[101] != "claude" {
+		t.Errorf("Comm[101] = %q, want claude", pt.Comm[101])
+	}
+}
+
+func TestResolveOpencode(t *testing.T) {
+	pt := ParseProcessTable(`
+  100     1 -zsh
+  101   100 /home/ana/.opencode/bin/opencode
+  200     1 -zsh
+  201   200 node /usr/local/lib/node_modules/opencode-ai/bin/opencode --model anthropic/claude-sonnet-4
+  300     1 -bash
+  301   300 bun /home/ana/.bun/install/global/node_modules/opencode-ai/bin/opencode
+  400     1 -zsh
+  401   400 node /opt/app/server.js
+`)
+	tests := []struct {
+		name    string
+		cmd     string
+		pid     int
+		want    string
+		wantPID int
+	}{
+		{"native binary in the foreground", "opencode", 100, "opencode", 100},
+		{"native binary under the shell", "zsh", 100, "opencode", 101},
+		{"hosted by node", "node", 200, "opencode", 201},
+		{"hosted by bun", "bun", 300, "opencode", 301},
+		{"other node program", "node", 400, "", 0},
+	}
+	for _, tt := range tests {
+		name, pid := ResolvePID(tt.cmd, tt.pid, &pt)
+		if name != tt.want || pid != tt.wantPID {
+			t.Errorf("%s: ResolvePID = %q, %d; want %q, %d", tt.name, name, pid, tt.want, tt.wantPID)
+		}
+	}
+}
+
+func TestRegisterCustom(t *testing.T) {
+	RegisterCustom(Spec{Name: "mycoder", BusyIndicators: []string{"(esc to cancel", "Thinking…"}, ArgsToken: "@acme/coder-cli"})
+	t.Cleanup(func() {
+		delete(registry, "mycoder")
+		delete(aliases, "@acme/coder-cli")
+	})
+
+	busy := []struct {
+		line string
+		want bool
+	}{
+		{"⠋ Reading files (esc to cancel, 3s)", true},
+		{"  Thinking…", true},
+		{"> implement the retry", false},
+		{"", false},
+	}
+	for _, tt := range busy {
+		if got := IsBusy("mycoder", []string{"  output", tt.line}); got != tt.want {
+			t.Errorf("IsBusy(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+	if !IsBusy("MyCoder", []string{"(esc to cancel)"}) {
+		t.Error("lookup by name is not case-insensitive")
+	}
+
+	pt := ParseProcessTable(`
+  100     1 -zsh
+  101   100 node /usr/local/lib/node_modules/@acme/coder-cli/dist/index.js
+`)
+	if name, pid := ResolvePID("zsh", 100, &pt); name != "mycoder" || pid != 101 {
+		t.Errorf("ResolvePID = %q, %d; want %q, %d", name, pid, "mycoder", 101)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	RegisterCustom(Spec{Name: "mycoder", ArgsToken: "@acme/coder-cli"})
+	Unregister("MyCoder")
+	if Lookup("mycoder") != nil || slices.Contains(All(), "mycoder") {
+		t.Error("provider still registered")
+	}
+	if IsAgent("node /usr/lib/node_modules/@acme/coder-cli/index.js") {
+		t.Error("args token still resolves")
+	}
+}
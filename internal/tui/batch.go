@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leo/agent-mux/internal/agent"
+)
+
+// batchConfirm lists the panes an action fanning out to several panes will
+// touch and waits for y (or enter) before running onYes; any other key
+// cancels. It is drawn in place of the preview, like a picker.
+type batchConfirm struct {
+	title   string
+	targets []batchTarget
+	onYes   func(m *Model) tea.Cmd
+}
+
+type batchTarget struct {
+	target string // session:window
+	path   string
+}
+
+// confirmBatch asks before running onYes on panes, listing each of them.
+func (m *Model) confirmBatch(title string, panes []*agent.Pane, onYes func(m *Model) tea.Cmd) {
+	targets := make([]batchTarget, len(panes))
+	for i, p := range panes {
+		targets[i] = batchTarget{target: p.Session + ":" + p.Window, path: cmp.Or(p.ShortPath, p.Path)}
+	}
+	m.batch = &batchConfirm{title: title, targets: targets, onYes: onYes}
+}
+
+func (m Model) handleBatchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.batch
+	m.batch = nil
+	switch msg.String() {
+	case "y", "Y", "enter":
+		return m, b.onYes(&m)
+	}
+	m.setFlash("cancelled", false)
+	return m, nil
+}
+
+func (b *batchConfirm) View(width, height int) string {
+	var s strings.Builder
+	s.WriteString(helpTitleStyle.Render(" " + truncate(b.title, width-2)))
+	s.WriteString("\n\n")
+	targetW := 0
+	for _, t := range b.targets {
+		targetW = max(targetW, dw(t.target))
+	}
+	// Title, blank line, the targets, blank line and the key hint; rows that
+	// don't fit are counted instead.
+	rows := b.targets
+	if room := height - 4; len(rows) > room {
+		rows = rows[:max(room-1, 0)]
+	}
+	for _, t := range rows {
+		line := "  " + pad(t.target, targetW) + "  "
+		s.WriteString(helpKeyStyle.UnsetWidth().Render(line) + helpDescStyle.Render(truncatePath(t.path, width-dw(line))))
+		s.WriteString("\n")
+	}
+	if n := len(b.targets) - len(rows); n > 0 {
+		s.WriteString(dimStyle.Render(fmt.Sprintf("  … and %d more", n)))
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+	hint := fmt.Sprintf(" y to confirm on %d panes, any other key cancels", len(b.targets))
+	s.WriteString(confirmStyle.Render(truncate(hint, width)))
+	return s.String()
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// chordAction is what a completed chord does.
+type chordAction int
+
+const (
+	chordNone chordAction = iota
+	chordKill
+	chordFirst
+	chordToggleCollapse
+	chordCollapseAll
+	chordExpandAll
+)
+
+// chord is a fixed key sequence such as "dd" bound to an action. When
+// timeout is set, a key arriving later than timeout after the previous one
+// no longer continues it.
+type chord struct {
+	keys    []string
+	action  chordAction
+	timeout time.Duration
+}
+
+// chords matches key presses against every chord at once, so chords sharing
+// a first key ("za", "zM") or set by different features ("gg" and the kill
+// chord) keep a single pending prefix instead of each tracking its own.
+type chords struct {
+	list    []chord
+	pending []string
+	last    time.Time
+}
+
+// feed advances the pending prefix with key. It returns the action of a
+// chord key completes; consumed reports that key belongs to a chord (complete
+// or still pending) and must not be handled as a normal key. A key that
+// doesn't continue the pending prefix cancels it and is tried again on its
+// own, so it can start another chord or reach the normal key handling.
+func (c *chords) feed(key string, now time.Time) (action chordAction, consumed bool) {
+	if len(c.pending) > 0 {
+		seq := append(slices.Clone(c.pending), key)
+		elapsed := now.Sub(c.last)
+		c.pending = nil
+		if action, consumed := c.advance(seq, elapsed, now); consumed {
+			return action, true
+		}
+	}
+	return c.advance([]string{key}, 0, now)
+}
+
+// advance matches seq, the keys pressed so far, elapsed after the previous
+// key. A complete chord wins over a longer one it is a prefix of.
+func (c *chords) advance(seq []string, elapsed time.Duration, now time.Time) (chordAction, bool) {
+	prefix := false
+	for _, ch := range c.list {
+		if len(ch.keys) < len(seq) || !slices.Equal(ch.keys[:len(seq)], seq) {
+			continue
+		}
+		if ch.timeout > 0 && elapsed > ch.timeout {
+			continue
+		}
+		if len(ch.keys) == len(seq) {
+			return ch.action, true
+		}
+		prefix = true
+	}
+	if !prefix {
+		return chordNone, false
+	}
+	c.pending = seq
+	c.last = now
+	return chordNone, true
+}
+
+// keys returns the keys of the chord bound to action, joined for display.
+func (c *chords) keys(action chordAction) string {
+	for _, ch := range c.list {
+		if ch.action == action {
+			return strings.Join(ch.keys, "")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// press is one key fed to a chord set, after gap since the previous one, and
+// what feed must return for it.
+type press struct {
+	key      string
+	gap      time.Duration
+	action   chordAction
+	consumed bool
+}
+
+func TestChordsFeed(t *testing.T) {
+	chordsWith := func(kill string, timeout time.Duration) chords {
+		keys, err := config.ChordKeys(kill)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chords{list: []chord{
+			{keys: keys, action: chordKill, timeout: timeout},
+			{keys: []string{"g", "g"}, action: chordFirst},
+			{keys: []string{"z", "a"}, action: chordToggleCollapse},
+			{keys: []string{"z", "M"}, action: chordCollapseAll},
+			{keys: []string{"z", "R"}, action: chordExpandAll},
+		}}
+	}
+	tests := []struct {
+		name    string
+		kill    string
+		timeout time.Duration
+		presses []press
+	}{
+		{"dd completes", "dd", 0, []press{
+			{"d", 0, chordNone, true},
+			{"d", 0, chordKill, true},
+		}},
+		{"dx cancels and re-feeds x", "dd", 0, []press{
+			{"d", 0, chordNone, true},
+			{"x", 0, chordNone, false},
+			{"d", 0, chordNone, true}, // the d of dx was dropped, not left pending
+			{"d", 0, chordKill, true},
+		}},
+		{"a second key starting another chord", "dd", 0, []press{
+			{"d", 0, chordNone, true},
+			{"g", 0, chordNone, true},
+			{"g", 0, chordFirst, true},
+		}},
+		{"zM and za share a prefix", "dd", 0, []press{
+			{"z", 0, chordNone, true},
+			{"M", 0, chordCollapseAll, true},
+			{"z", 0, chordNone, true},
+			{"a", 0, chordToggleCollapse, true},
+			{"z", 0, chordNone, true},
+			{"j", 0, chordNone, false},
+		}},
+		{"kill chord within the timeout", "dd", 300 * time.Millisecond, []press{
+			{"d", 0, chordNone, true},
+			{"d", 200 * time.Millisecond, chordKill, true},
+		}},
+		{"kill chord past the timeout", "dd", 300 * time.Millisecond, []press{
+			{"d", 0, chordNone, true},
+			{"d", time.Second, chordNone, true}, // starts over
+			{"d", 100 * time.Millisecond, chordKill, true},
+		}},
+		{"kill chord at exactly the timeout", "dd", 300 * time.Millisecond, []press{
+			{"d", 0, chordNone, true},
+			{"d", 300 * time.Millisecond, chordKill, true},
+		}},
+		{"no timeout on chords without one", "dd", 300 * time.Millisecond, []press{
+			{"g", 0, chordNone, true},
+			{"g", time.Minute, chordFirst, true},
+		}},
+		{"single-key kill chord that prefixes gg", "g", 0, []press{
+			{"g", 0, chordKill, true},
+			{"g", 0, chordKill, true},
+		}},
+		{"named single key", "ctrl+k", 0, []press{
+			{"ctrl+k", 0, chordKill, true},
+			{"d", 0, chordNone, false},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := chordsWith(tt.kill, tt.timeout)
+			now := time.Now()
+			for i, p := range tt.presses {
+				now = now.Add(p.gap)
+				action, consumed := c.feed(p.key, now)
+				if action != p.action || consumed != p.consumed {
+					t.Fatalf("press %d (%s): feed = %v, %v; want %v, %v", i, p.key, action, consumed, p.action, p.consumed)
+				}
+			}
+		})
+	}
+}
+
+func TestChordsKeys(t *testing.T) {
+	c := chords{list: []chord{
+		{keys: []string{"d", "d"}, action: chordKill},
+		{keys: []string{"g", "g"}, action: chordFirst},
+	}}
+	if got := c.keys(chordKill); got != "dd" {
+		t.Errorf("keys(chordKill) = %q, want dd", got)
+	}
+	if got := c.keys(chordExpandAll); got != "" {
+		t.Errorf("keys(chordExpandAll) = %q, want empty", got)
+	}
+}
+
+func TestNewModelKillChord(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		keys    []string
+		timeout time.Duration
+	}{
+		{"default", config.Config{}, []string{"d", "d"}, 0},
+		{"single key", config.Config{KillChord: "x"}, []string{"x"}, 0},
+		{"with timeout", config.Config{KillChord: "dd", KillChordTimeoutMs: 250}, []string{"d", "d"}, 250 * time.Millisecond},
+		{"unpressable falls back to the default", config.Config{KillChord: "ddd"}, []string{"d", "d"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, tt.cfg)
+			m := NewModel("", Options{})
+			i := slices.IndexFunc(m.chords.list, func(c chord) bool { return c.action == chordKill })
+			if i < 0 {
+				t.Fatal("no kill chord")
+			}
+			if c := m.chords.list[i]; !slices.Equal(c.keys, tt.keys) || c.timeout != tt.timeout {
+				t.Errorf("kill chord = %q within %v, want %q within %v", c.keys, c.timeout, tt.keys, tt.timeout)
+			}
+		})
+	}
+}
+
+func TestHelpShowsChords(t *testing.T) {
+	useConfig(t, config.Config{KillChord: "x"})
+	// Help lines are the key, padded, then its description.
+	keyFor := make(map[string]string)
+	for line := range strings.Lines(ansi.Strip(NewModel("", Options{}).renderHelp())) {
+		if f := strings.Fields(line); len(f) > 1 {
+			keyFor[strings.Join(f[1:], " ")] = f[0]
+		}
+	}
+	for desc, want := range map[string]string{
+		"kill pane":                 "x",
+		"collapse/expand workspace": "za",
+		"collapse/expand all":       "zM/zR",
+		"go to first":               "gg",
+	} {
+		if got := keyFor[desc]; got != want {
+			t.Errorf("help shows %q for %s, want %q", got, desc, want)
+		}
+	}
+}
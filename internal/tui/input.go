@@ -0,0 +1,63 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inputLine is a minimal single-line editor rendered in the status bar.
+// onSubmit runs with the entered text when the user presses enter.
+type inputLine struct {
+	prompt   string
+	value    []rune
+	onSubmit func(m *Model, value string) tea.Cmd
+}
+
+// handleInputKey routes a key press to the active input line.
+func (m Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	in := m.input
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.input = nil
+		return m, in.onSubmit(&m, string(in.value))
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.input = nil
+	case tea.KeyBackspace:
+		if len(in.value) > 0 {
+			in.value = in.value[:len(in.value)-1]
+		}
+	case tea.KeyCtrlU:
+		in.value = in.value[:0]
+	case tea.KeySpace:
+		in.value = append(in.value, ' ')
+	case tea.KeyRunes:
+		in.value = append(in.value, msg.Runes...)
+	}
+	return m, nil
+}
+
+func (in *inputLine) View(width int) string {
+	text := " " + in.prompt + string(in.value)
+	cursor := inputCursorStyle.Render(" ")
+	return inputStyle.Render(text) + cursor + inputStyle.Render(spaces(width-dw(text)-1))
+}
+
+// confirmPrompt is a y/n question rendered in the status bar. onYes runs when
+// the user answers y; any other key cancels.
+type confirmPrompt struct {
+	text  string
+	onYes func(m *Model) tea.Cmd
+}
+
+func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.confirm
+	m.confirm = nil
+	if k := msg.String(); k == "y" || k == "Y" {
+		return m, c.onYes(&m)
+	}
+	return m, nil
+}
+
+func (c *confirmPrompt) View(width int) string {
+	text := " " + c.text + " (y/n)"
+	return confirmStyle.Render(truncate(text, width) + spaces(width-dw(text)))
+}
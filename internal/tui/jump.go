@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jumpAlphabet orders label characters by ease of typing (home row first).
+const jumpAlphabet = "asdfghjklqwertyuiopzxcvbnm"
+
+// jumpState is the active quick-select overlay: each visible workspace header
+// gets a label, and typing it moves the cursor to that workspace's first pane.
+type jumpState struct {
+	labels map[int]string // item index -> label
+	typed  string
+}
+
+// jumpLabels assigns labels to n targets. Single letters are used while they
+// suffice; beyond that every label is two letters so none is a prefix of
+// another.
+func jumpLabels(n int) []string {
+	labels := make([]string, 0, n)
+	if n <= len(jumpAlphabet) {
+		for i := range n {
+			labels = append(labels, jumpAlphabet[i:i+1])
+		}
+		return labels
+	}
+	for _, a := range jumpAlphabet {
+		for _, b := range jumpAlphabet {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(a)+string(b))
+		}
+	}
+	return labels
+}
+
+// startJump labels the workspace headers currently on screen.
+func (m *Model) startJump() {
+	h := m.bodyHeight()
+	start := VisibleSlice(len(m.items), max(m.cursor, 0), h)
+	end := min(start+h, len(m.items))
+	var headers []int
+	for i := start; i < end; i++ {
+		if k := m.items[i].Kind; k == KindWorkspace || k == KindProjectGroup {
+			headers = append(headers, i)
+		}
+	}
+	if len(headers) == 0 {
+		return
+	}
+	labels := jumpLabels(len(headers))
+	js := &jumpState{labels: make(map[int]string, len(headers))}
+	for i, idx := range headers {
+		js.labels[idx] = labels[i]
+	}
+	m.jump = js
+}
+
+// handleJumpKey narrows the typed label; an exact match jumps, a key that
+// matches no label (or esc) dismisses the overlay.
+func (m Model) handleJumpKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type != tea.KeyRunes {
+		m.jump = nil
+		return m, nil
+	}
+	typed := m.jump.typed + string(msg.Runes)
+	prefix := false
+	for idx, label := range m.jump.labels {
+		if label == typed {
+			m.jump = nil
+			m.cursor = NextPane(m.items, idx)
+			return m, m.newPreviewCmd()
+		}
+		if strings.HasPrefix(label, typed) {
+			prefix = true
+		}
+	}
+	if !prefix {
+		m.jump = nil
+		return m, nil
+	}
+	m.jump.typed = typed
+	return m, nil
+}
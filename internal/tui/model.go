@@ -1,6 +1,11 @@
 package tui
 
 import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -8,7 +13,10 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/leo/agent-mux/internal/agent"
+	"github.com/leo/agent-mux/internal/config"
+	"github.com/leo/agent-mux/internal/provider"
 )
 
 type panesLoadedMsg struct {
@@ -20,23 +28,73 @@ type previewLoadedMsg struct {
 	paneID  string
 	content string
 	gen     int
+	second  bool // for the second column of a split preview
+}
+
+type paneKilledMsg struct {
+	pane agent.Pane // the pane that was killed, kept for relaunching with U
+	err  error
+	note string // shown in the status bar, e.g. how a graceful stop went
+}
+
+// flashMsg reports the outcome of a background action in the status bar.
+type flashMsg struct {
+	text string
+	err  error
+}
+
+// tileConfirmMsg carries the panes T would tile, and the windows moving
+// them would close, to the confirmation.
+type tileConfirmMsg struct {
+	panes   []*agent.Pane
+	closing []string
+	err     error
+}
+
+// tiledMsg reports the window TileTargets made, and the panes it couldn't
+// move.
+type tiledMsg struct {
+	window string
+	err    error
 }
 
-type paneKilledMsg struct{ err error }
 type previewTickMsg struct{ gen int }
 type previewDebounceMsg struct{ gen int }
 type panesTickMsg time.Time
 
+// healthTickMsg redraws the refresh age in the status bar. It ticks on its
+// own, since a hung tmux also stalls the pane and preview loads.
+type healthTickMsg struct{}
+
+func healthTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return healthTickMsg{}
+	})
+}
+
 func previewTickCmd(gen int) tea.Cmd {
 	return tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
 		return previewTickMsg{gen: gen}
 	})
 }
 
+// pollInterval returns the delay before the next pane refresh. Refreshes are
+// chained (the next tick is scheduled when a load finishes), so a short
+// interval never overlaps loads.
 func (m Model) pollInterval() time.Duration {
 	if m.refreshCount <= 2 {
 		return 500 * time.Millisecond
 	}
+	// Poll faster while a pane needs attention, to catch it resolving or
+	// escalating promptly.
+	for _, p := range m.panes {
+		if p.Status == agent.StatusNeedsAttention {
+			if ms := config.Get().AttentionPollMs; ms > 0 {
+				return time.Duration(ms) * time.Millisecond
+			}
+			return 500 * time.Millisecond
+		}
+	}
 	return 2 * time.Second
 }
 
@@ -51,13 +109,24 @@ func loadPanes() tea.Msg {
 	return panesLoadedMsg{panes: panes, err: err}
 }
 
-func loadPreview(target, paneID string, lines, gen int) tea.Cmd {
+// otherPanesMsg carries the non-agent panes shown while showOthers is on.
+type otherPanesMsg struct {
+	panes []agent.Pane
+	err   error
+}
+
+func loadOtherPanes() tea.Msg {
+	panes, err := agent.ListOtherPanes()
+	return otherPanesMsg{panes: panes, err: err}
+}
+
+func loadPreview(target, paneID string, lines, gen int, second bool) tea.Cmd {
 	return func() tea.Msg {
 		content, err := agent.CapturePane(target, lines)
 		if err != nil {
 			content = "error: " + err.Error()
 		}
-		return previewLoadedMsg{paneID: paneID, content: content, gen: gen}
+		return previewLoadedMsg{paneID: paneID, content: sanitizePreview(content), gen: gen, second: second}
 	}
 }
 
@@ -71,6 +140,10 @@ type Model struct {
 	preview            viewport.Model
 	previewFor         string
 	lastPreviewContent string
+	previewShown       string         // pane whose output is in the preview (previewFor is cleared to force reloads)
+	second             viewport.Model // right column of the split preview on wide terminals
+	secondFor          string
+	lastSecondContent  string
 	previewGen         int
 	width              int
 	height             int
@@ -78,23 +151,99 @@ type Model struct {
 	loaded             bool
 	firstRefreshDone   bool
 	showHelp           bool
-	pendingD           bool
-	pendingG           bool
+	chords             chords
 	count              int
 	sidebarWidth       int
 	dragging           bool
 	tmuxSession        string
 	state              agent.State
 	refreshCount       int
+	lastRefresh        time.Time // last successful pane load, for the status bar's health indicator
 	projectWinWidth    map[string]int
+	duplicates         map[string]int       // pane id -> panes of its provider in its directory, when over 1 (mark_duplicates)
+	groupSizes         map[string]groupSize // group key -> its listed panes, for header counts
+	notes              map[string]string
+	input              *inputLine
+	picker             *picker
+	flash              string
+	flashErr           bool
+	flashAt            time.Time
+	title              windowTitle
+	popups             bool // tmux can show display-popup (3.2+); checked once at startup
+	jump               *jumpState
+	confirm            *confirmPrompt
+	batch              *batchConfirm
+	peek               bool
+	collapsed          map[string]bool // TreeItem.Group -> collapsed
+	autoExpanded       map[string]bool // collapsed groups opened by expand_on_attention, to close again
+	groupedProjects    map[string]bool
+	completed          map[string]time.Time // PaneID -> when a completion summary appeared
+	peekPane           string               // pane previewed via shift+arrows; "" follows the cursor
+	onlySession        string               // when set, list only panes of this tmux session
+	pickerMode         bool                 // list only, full width, no preview
+	showOthers         bool                 // also list non-agent panes, for debugging detection
+	flat               bool                 // list panes without workspace headers
+	previewHidden      bool                 // preview toggled off with tab; the list takes the full width
+	printTarget        bool                 // enter records the selection instead of switching
+	selected           string               // target chosen with enter in printTarget mode
+	lastKilled         *agent.Pane          // most recently killed pane, for U to relaunch
+	selfPane           string               // pane agent-mux runs in ($TMUX_PANE), never listed
+	others             map[string]*agent.Pane
+}
+
+// Options are the command-line choices that shape the TUI.
+type Options struct {
+	CurrentSessionOnly bool // list only the tmux session agent-mux runs in
+	Picker             bool // hide the preview: a compact full-width pane switcher
+	PrintTarget        bool // enter selects the pane for Selected instead of switching to it
 }
 
-func NewModel(tmuxSession string) Model {
+// windowTitle tracks the attention badge on agent-mux's own tmux window.
+type windowTitle struct {
+	paneID     string // $TMUX_PANE; empty when the badge is disabled
+	orig       string
+	autoRename bool
+	count      int
+}
+
+func NewModel(tmuxSession string, opts Options) Model {
 	m := Model{
-		preview:     viewport.New(40, 20),
-		tmuxSession: tmuxSession,
-		panes:       make(map[string]*agent.Pane),
-		reconciler:  agent.NewReconciler(),
+		preview:      viewport.New(40, 20),
+		second:       viewport.New(40, 20),
+		tmuxSession:  tmuxSession,
+		panes:        make(map[string]*agent.Pane),
+		reconciler:   agent.NewReconciler(),
+		notes:        agent.LoadNotes(),
+		collapsed:    make(map[string]bool),
+		autoExpanded: make(map[string]bool),
+		completed:    make(map[string]time.Time),
+		pickerMode:   opts.Picker,
+		printTarget:  opts.PrintTarget,
+	}
+	cfg := config.Get()
+	// Load has rejected a kill_chord that can't be pressed; one set some
+	// other way falls back to the default.
+	killKeys, err := config.ChordKeys(cfg.KillChord)
+	if err != nil {
+		killKeys, _ = config.ChordKeys("")
+	}
+	m.chords.list = []chord{
+		{keys: killKeys, action: chordKill, timeout: time.Duration(cfg.KillChordTimeoutMs) * time.Millisecond},
+		{keys: []string{"g", "g"}, action: chordFirst},
+		{keys: []string{"z", "a"}, action: chordToggleCollapse},
+		{keys: []string{"z", "M"}, action: chordCollapseAll},
+		{keys: []string{"z", "R"}, action: chordExpandAll},
+	}
+	m.flat = cfg.FlatList
+	m.selfPane = os.Getenv("TMUX_PANE")
+	m.popups = agent.PopupSupported()
+	if opts.CurrentSessionOnly {
+		m.onlySession, _ = agent.CurrentSession()
+	}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" && cfg.TitleBadge {
+		if name, auto, err := agent.WindowName(pane); err == nil {
+			m.title = windowTitle{paneID: pane, orig: name, autoRename: auto}
+		}
 	}
 
 	state, stateOK := agent.LoadState()
@@ -153,7 +302,7 @@ func NewModel(tmuxSession string) Model {
 	}
 	m.rebuildItems()
 
-	if att := m.firstAttentionPane(); att >= 0 {
+	if att := m.firstPaneByPreference(); att >= 0 {
 		m.cursor = att
 	} else if stateOK && (state.LastPosition.PaneID != "" || state.LastPosition.PaneTarget != "") {
 		posID := state.LastPosition.PaneID
@@ -179,40 +328,122 @@ func NewModel(tmuxSession string) Model {
 func (m *Model) rebuildItems() {
 	sorted := make([]*agent.Pane, 0, len(m.panes))
 	groupedProjects := make(map[string]bool)
+	bySession := groupBySession()
 	for _, p := range m.panes {
+		if !m.listed(p) {
+			continue
+		}
 		sorted = append(sorted, p)
-		if p.ProjectRoot != "" && p.Path != p.ProjectRoot {
+		if p.ProjectRoot != "" && p.Path != p.ProjectRoot && !bySession {
 			groupedProjects[p.ProjectRoot] = true
 		}
 	}
+	m.groupedProjects = groupedProjects
+
+	// Workspaces matching priority_paths come first. Within each tier,
+	// manually ordered workspaces lead, in the saved order; the rest follow
+	// in tmux order.
+	priority := config.Get().PriorityPaths
+	rank := make(map[string]int, len(m.state.WorkspaceOrder))
+	for i, key := range m.state.WorkspaceOrder {
+		rank[key] = i
+	}
 	sort.Slice(sorted, func(i, j int) bool {
 		if sorted[i].Stashed != sorted[j].Stashed {
 			return !sorted[i].Stashed
 		}
+		pi := config.Matches(priority, m.workspaceKey(sorted[i]))
+		pj := config.Matches(priority, m.workspaceKey(sorted[j]))
+		if pi != pj {
+			return pi
+		}
+		ri, iok := rank[m.workspaceKey(sorted[i])]
+		rj, jok := rank[m.workspaceKey(sorted[j])]
+		if iok != jok {
+			return iok
+		}
+		if iok && ri != rj {
+			return ri < rj
+		}
 		if sorted[i].Order != sorted[j].Order {
 			return sorted[i].Order < sorted[j].Order
 		}
 		return sorted[i].Target < sorted[j].Target
 	})
+	m.sortByStatus(sorted)
 
-	// Pre-compute the max window-label width per project so worktree labels
-	// in the same project line up vertically.
+	// Pre-compute the max window-label width per project (or session) so
+	// worktree labels in the same project line up vertically.
 	projectWinWidth := make(map[string]int)
 	for _, p := range sorted {
-		if groupedProjects[p.ProjectRoot] {
+		if groupedProjects[p.ProjectRoot] || bySession {
 			label := p.Window + ":" + p.WindowName
 			if p.WindowName == "" {
 				label = p.Session + ":" + p.Window
 			}
-			if w := lipgloss.Width(label); w > projectWinWidth[p.ProjectRoot] {
-				projectWinWidth[p.ProjectRoot] = w
+			key := m.workspaceKey(p)
+			if w := lipgloss.Width(label); w > projectWinWidth[key] {
+				projectWinWidth[key] = w
 			}
 		}
 	}
 	m.projectWinWidth = projectWinWidth
+	m.duplicates = duplicatePanes(sorted)
+	m.groupSizes = make(map[string]groupSize)
+	for _, p := range sorted {
+		key := m.groupOf(p)
+		g := m.groupSizes[key]
+		g.panes++
+		if p.Status.WantsAttention() {
+			g.attention++
+		}
+		m.groupSizes[key] = g
+	}
+
+	var items []TreeItem
+	if m.flat {
+		items = m.flattenTree(sorted)
+	} else {
+		items = m.groupTree(sorted)
+	}
+	if recent := m.recentProjects(); len(recent) > 0 {
+		items = append(items,
+			TreeItem{Kind: KindSectionHeader},
+			TreeItem{Kind: KindSectionHeader, HeaderTitle: "recent"},
+		)
+		for _, r := range recent {
+			items = append(items, TreeItem{Kind: KindRecent, Group: recentKey(r)})
+		}
+	}
+	if m.showOthers && len(m.others) > 0 {
+		others := slices.SortedFunc(maps.Values(m.others), func(a, b *agent.Pane) int {
+			return a.Order - b.Order
+		})
+		items = append(items,
+			TreeItem{Kind: KindSectionHeader},
+			TreeItem{Kind: KindSectionHeader, HeaderTitle: "other panes"},
+		)
+		for _, p := range others {
+			if m.listed(p) {
+				items = append(items, TreeItem{Kind: KindOther, PaneID: p.PaneID})
+			}
+		}
+	}
+	m.items = items
+}
 
+// groupSize counts the panes of a group and those of them wanting attention.
+type groupSize struct {
+	panes, attention int
+}
+
+// groupTree lists sorted under workspace headers, or project headers for
+// projects with worktrees, with stashed panes in their own section. With
+// group_by "session" each tmux session is one workspace, titled by name.
+func (m Model) groupTree(sorted []*agent.Pane) []TreeItem {
 	var items []TreeItem
-	prevPath := ""
+	bySession := groupBySession()
+	prevKey := ""
 	prevProject := ""
 	inStashed := false
 	for _, p := range sorted {
@@ -222,26 +453,289 @@ func (m *Model) rebuildItems() {
 				TreeItem{Kind: KindSectionHeader},
 				TreeItem{Kind: KindSectionHeader, HeaderTitle: "stashed"},
 			)
-			prevPath = ""
+			prevKey = ""
 			prevProject = ""
 		}
 
-		if groupedProjects[p.ProjectRoot] {
+		group := m.groupOf(p)
+		if m.groupedProjects[p.ProjectRoot] {
 			if p.ProjectRoot != prevProject {
-				items = append(items, TreeItem{Kind: KindProjectGroup, PaneID: p.PaneID})
+				items = append(items, TreeItem{Kind: KindProjectGroup, PaneID: p.PaneID, Group: group, Collapsed: m.collapsed[group]})
 				prevProject = p.ProjectRoot
 			}
-			items = append(items, TreeItem{Kind: KindPane, PaneID: p.PaneID})
+			if !m.collapsed[group] {
+				items = append(items, TreeItem{Kind: KindPane, PaneID: p.PaneID, Group: group})
+			}
 		} else {
-			if p.Path != prevPath {
-				prevPath = p.Path
-				items = append(items, TreeItem{Kind: KindWorkspace, PaneID: p.PaneID})
+			if key := m.workspaceKey(p); key != prevKey {
+				prevKey = key
+				header := TreeItem{Kind: KindWorkspace, PaneID: p.PaneID, Group: group, Collapsed: m.collapsed[group]}
+				if bySession {
+					header.HeaderTitle = p.Session
+				}
+				items = append(items, header)
+			}
+			if !m.collapsed[group] {
+				items = append(items, TreeItem{Kind: KindPane, PaneID: p.PaneID, Group: group})
 			}
-			items = append(items, TreeItem{Kind: KindPane, PaneID: p.PaneID})
 			prevProject = ""
 		}
 	}
-	m.items = items
+	return items
+}
+
+// sortByStatus reorders each workspace's panes in sorted by status_priority,
+// keeping tmux order within a status. Workspaces stay where they are.
+func (m Model) sortByStatus(sorted []*agent.Pane) {
+	order := config.Get().StatusPriority
+	if len(order) == 0 {
+		return
+	}
+	rank := func(p *agent.Pane) int { return slices.Index(order, statusClass(p.Status)) }
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sorted[end].Stashed == sorted[start].Stashed &&
+			m.workspaceKey(sorted[end]) == m.workspaceKey(sorted[start]) {
+			end++
+		}
+		slices.SortStableFunc(sorted[start:end], func(a, b *agent.Pane) int {
+			return rank(a) - rank(b)
+		})
+		start = end
+	}
+}
+
+// duplicatePanes counts, with mark_duplicates on, the panes of each provider
+// in each directory, and returns the count for every pane that isn't alone.
+func duplicatePanes(panes []*agent.Pane) map[string]int {
+	if !config.Get().MarkDuplicates {
+		return nil
+	}
+	type key struct{ path, provider string }
+	counts := make(map[key]int)
+	for _, p := range panes {
+		counts[key{p.Path, p.Provider}]++
+	}
+	dups := make(map[string]int)
+	for _, p := range panes {
+		if n := counts[key{p.Path, p.Provider}]; n > 1 {
+			dups[p.PaneID] = n
+		}
+	}
+	return dups
+}
+
+// recentKey is the Group of a recent project's tree item.
+func recentKey(r agent.RecentProject) string {
+	return "recent/" + r.Provider + ":" + r.Path
+}
+
+// recentProjects returns the show_recent_projects most recent directories
+// from the history that still exist and have no agent running in them.
+func (m Model) recentProjects() []agent.RecentProject {
+	n := config.Get().ShowRecentProjects
+	if n <= 0 {
+		return nil
+	}
+	live := make(map[string]bool, len(m.panes))
+	for _, p := range m.panes {
+		live[p.Path] = true
+	}
+	var out []agent.RecentProject
+	for _, r := range m.state.Recent {
+		if len(out) == n {
+			break
+		}
+		if live[r.Path] {
+			continue
+		}
+		if dirGone(r.Path) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// dirGone reports whether path is no longer a directory, for actions that
+// start something in a directory known from an earlier refresh.
+func dirGone(path string) bool {
+	info, err := os.Stat(path)
+	return err != nil || !info.IsDir()
+}
+
+// recentAt returns the recent project item stands for, or nil.
+func (m Model) recentAt(item TreeItem) *agent.RecentProject {
+	if item.Kind != KindRecent {
+		return nil
+	}
+	for i := range m.state.Recent {
+		if recentKey(m.state.Recent[i]) == item.Group {
+			return &m.state.Recent[i]
+		}
+	}
+	return nil
+}
+
+// resumeRecent starts r's provider in a new window in its directory,
+// continuing its last conversation where the provider can, then switches to
+// it and quits like enter on a pane.
+func (m Model) resumeRecent(r agent.RecentProject) (tea.Model, tea.Cmd) {
+	if dirGone(r.Path) {
+		m.setFlash(cmp.Or(r.ShortPath, r.Path)+" no longer exists", true)
+		return m, nil
+	}
+	target, err := agent.NewAgentPane("", r.Path, provider.ResumeCommand(r.Provider))
+	if err != nil {
+		m.setFlash(err.Error(), true)
+		return m, nil
+	}
+	if m.printTarget {
+		m.selected = target
+	} else {
+		_ = agent.SwitchToPane(target)
+	}
+	m.saveState()
+	m.restoreTitle()
+	return m, tea.Quit
+}
+
+// flattenTree lists sorted as bare pane rows, with no workspace or section
+// headers; rows show their path inline instead (see renderPaneRow).
+func (m Model) flattenTree(sorted []*agent.Pane) []TreeItem {
+	items := make([]TreeItem, len(sorted))
+	for i, p := range sorted {
+		items[i] = TreeItem{Kind: KindPane, PaneID: p.PaneID, Group: m.groupOf(p)}
+	}
+	return items
+}
+
+// listed reports whether p passes the session filter and appears in the tree.
+// The pane agent-mux itself runs in is never listed, even if detection
+// mistakes it for an agent (e.g. agent-mux started from an agent's shell).
+func (m Model) listed(p *agent.Pane) bool {
+	if p.PaneID == m.selfPane {
+		return false
+	}
+	return m.onlySession == "" || p.Session == m.onlySession
+}
+
+// groupOf returns the collapse/grouping key of the header p is listed under:
+// its session with group_by "session", its project root when the project has
+// worktrees, otherwise its path. The stashed section keeps its own groups.
+func (m Model) groupOf(p *agent.Pane) string {
+	key := "path:" + p.Path
+	switch {
+	case groupBySession():
+		key = "session:" + p.Session
+	case m.groupedProjects[p.ProjectRoot]:
+		key = "project:" + p.ProjectRoot
+	}
+	if p.Stashed {
+		key = "stashed/" + key
+	}
+	return key
+}
+
+// completedGlyphDuration is how long a pane shows the completion glyph after
+// its provider prints a completion summary.
+const completedGlyphDuration = 5 * time.Second
+
+// expandOnAttention applies expand_on_attention before panes replaces the
+// current panes: collapsed groups with a pane that just started needing
+// attention open, and groups it opened close again once nothing in them
+// needs attention. With "focus" it returns the id of the pane the cursor
+// should move to, else "".
+func (m *Model) expandOnAttention(panes map[string]*agent.Pane) string {
+	mode := config.Get().ExpandOnAttention
+	if mode == "" {
+		return ""
+	}
+	waiting := make(map[string]bool)
+	focus := ""
+	for id, p := range panes {
+		if p.Status != agent.StatusNeedsAttention {
+			continue
+		}
+		group := m.groupOf(p)
+		waiting[group] = true
+		if old := m.panes[id]; old != nil && old.Status == agent.StatusNeedsAttention {
+			continue
+		}
+		if m.collapsed[group] {
+			delete(m.collapsed, group)
+			m.autoExpanded[group] = true
+		}
+		if mode == "focus" && (focus == "" || p.Order < panes[focus].Order) {
+			focus = id
+		}
+	}
+	for group := range m.autoExpanded {
+		if !waiting[group] {
+			m.collapsed[group] = true
+			delete(m.autoExpanded, group)
+		}
+	}
+	return focus
+}
+
+// trackCompleted records panes whose completion summary just appeared and
+// forgets expired or vanished ones. Summaries already on screen at startup
+// are not flagged.
+func (m *Model) trackCompleted(panes map[string]*agent.Pane, firstLoad bool) {
+	now := time.Now()
+	for id, t := range m.completed {
+		if panes[id] == nil || now.Sub(t) >= completedGlyphDuration {
+			delete(m.completed, id)
+		}
+	}
+	if firstLoad {
+		return
+	}
+	for id, p := range panes {
+		if old := m.panes[id]; p.HeuristicDone && old != nil && !old.HeuristicDone {
+			m.completed[id] = now
+		}
+	}
+}
+
+// justCompleted reports whether p should show the completion glyph.
+func (m Model) justCompleted(p *agent.Pane) bool {
+	t, ok := m.completed[p.PaneID]
+	return ok && time.Since(t) < completedGlyphDuration
+}
+
+// workspaceKey returns the path a pane's workspace is ordered by: the project
+// root for worktree projects, otherwise the pane's own path. Grouped by
+// session, it is the session name.
+func (m Model) workspaceKey(p *agent.Pane) string {
+	switch {
+	case groupBySession():
+		return p.Session
+	case m.groupedProjects[p.ProjectRoot]:
+		return p.ProjectRoot
+	}
+	return p.Path
+}
+
+// groupBySession reports whether group_by makes each tmux session a
+// workspace. ("repo" needs nothing here: it widens ProjectRoot, and panes
+// below a repository's top group under it like worktrees do.)
+func groupBySession() bool {
+	return config.Get().GroupBy == "session"
+}
+
+// groupPanes returns the panes listed under group (including those hidden by
+// collapse), in display order.
+func (m Model) groupPanes(group string) []*agent.Pane {
+	var panes []*agent.Pane
+	for _, p := range m.panes {
+		if m.listed(p) && m.groupOf(p) == group {
+			panes = append(panes, p)
+		}
+	}
+	sort.Slice(panes, func(i, j int) bool { return panes[i].Order < panes[j].Order })
+	return panes
 }
 
 // resolvePane returns the pane for the tree item at idx, or nil.
@@ -263,7 +757,7 @@ func (m Model) findPaneByID(paneID string) int {
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(loadPanes, m.previewCmd())
+	return tea.Batch(loadPanes, m.previewCmd(), healthTickCmd())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -272,8 +766,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.preview.Width = m.previewWidth()
-		m.preview.Height = m.height
-		return m, nil
+		m.preview.Height = m.bodyHeight()
+		// Re-lay out the last content against the new size right away, and
+		// drop captures already in flight for the old size: the debounced
+		// reload bumps previewGen and captures for the new height.
+		m.showPreview(m.lastPreviewContent)
+		m.second.SetContent(m.lastSecondContent)
+		m.second.GotoBottom()
+		if !m.loaded {
+			return m, nil
+		}
+		return m, m.newPreviewCmd()
 
 	case panesLoadedMsg:
 		firstLoad := !m.firstRefreshDone
@@ -285,6 +788,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, panesTickCmd(m.pollInterval())
 		}
 		m.err = nil
+		m.lastRefresh = time.Now()
 
 		// Preserve stashed state before reconciliation.
 		stashed := make(map[string]bool, len(m.panes))
@@ -303,15 +807,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			p.Stashed = stashed[p.PaneID]
 			newPanes[p.PaneID] = p
 		}
+		m.trackCompleted(newPanes, firstLoad)
+		focus := m.expandOnAttention(newPanes)
+		asked := highlightedLine(m.panes[m.previewShown])
 		m.panes = newPanes
+		if highlightedLine(newPanes[m.previewShown]) != asked {
+			m.showPreview(m.lastPreviewContent)
+		}
 
+		var selected TreeItem
+		if m.cursor >= 0 && m.cursor < len(m.items) {
+			selected = m.items[m.cursor]
+		}
 		m.rebuildItems()
+		m.updateTitleBadge()
+		if m.peekIndex() < 0 {
+			m.peekPane = ""
+		}
 		if firstLoad {
-			if att := m.firstAttentionPane(); att >= 0 {
+			if att := m.firstPaneByPreference(); att >= 0 {
 				m.cursor = att
 			} else {
 				m.cursor = NearestPane(m.items, m.cursor)
 			}
+		} else if i := m.findPaneByID(focus); focus != "" && i >= 0 {
+			m.cursor = i
+			return m, tea.Batch(panesTickCmd(m.pollInterval()), m.newPreviewCmd())
+		} else if i := indexOfItem(m.items, selected); i >= 0 {
+			// Follow the selected pane when panes come and go above it.
+			m.cursor = i
 		} else {
 			m.cursor = NearestPane(m.items, m.cursor)
 		}
@@ -321,17 +845,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.gen != m.previewGen {
 			return m, nil
 		}
+		// Unchanged content is only skipped for the same pane: another
+		// pane with identical output must still reset the scroll position.
+		if msg.second {
+			content := strings.TrimRight(msg.content, "\n")
+			if msg.paneID != m.secondFor || content != m.lastSecondContent {
+				m.secondFor = msg.paneID
+				m.lastSecondContent = content
+				m.second.SetContent(content)
+				m.second.GotoBottom()
+			}
+			return m, nil
+		}
 		m.previewFor = msg.paneID
 		content := strings.TrimRight(msg.content, "\n")
-		if content != m.lastPreviewContent {
+		if msg.paneID != m.previewShown || content != m.lastPreviewContent {
+			m.previewShown = msg.paneID
 			m.lastPreviewContent = content
-			m.preview.SetContent(content)
-			m.preview.GotoBottom()
+			m.showPreview(content)
 		}
 		return m, previewTickCmd(m.previewGen)
 
 	case previewDebounceMsg:
-		if msg.gen != m.previewGen {
+		if msg.gen != m.previewGen || !m.capturing() {
 			return m, nil
 		}
 		m.previewFor = ""
@@ -341,7 +877,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, previewTickCmd(m.previewGen)
 
 	case previewTickMsg:
-		if msg.gen != m.previewGen {
+		if msg.gen != m.previewGen || !m.capturing() {
 			return m, nil
 		}
 		m.previewFor = ""
@@ -350,14 +886,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, previewTickCmd(m.previewGen)
 
+	case healthTickMsg:
+		return m, healthTickCmd()
+
 	case panesTickMsg:
+		if m.showOthers {
+			return m, tea.Batch(loadPanes, loadOtherPanes)
+		}
 		return m, loadPanes
 
+	case otherPanesMsg:
+		if !m.showOthers {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.setFlash(msg.err.Error(), true)
+			return m, nil
+		}
+		m.others = make(map[string]*agent.Pane, len(msg.panes))
+		for i := range msg.panes {
+			m.others[msg.panes[i].PaneID] = &msg.panes[i]
+		}
+		m.rebuildItems()
+		m.cursor = NearestPane(m.items, m.cursor)
+		return m, nil
+
+	case flashMsg:
+		if msg.err != nil {
+			m.setFlash(msg.err.Error(), true)
+		} else {
+			m.setFlash(msg.text, false)
+		}
+		return m, nil
+
+	case tileConfirmMsg:
+		if msg.err != nil {
+			m.setFlash(msg.err.Error(), true)
+			return m, nil
+		}
+		ids := make([]string, len(msg.panes))
+		for i, p := range msg.panes {
+			ids[i] = p.PaneID
+		}
+		title := "Tile into one window:"
+		if len(msg.closing) > 0 {
+			title = "Tile into one window, closing " + strings.Join(msg.closing, ", ") + ":"
+		}
+		m.confirmBatch(title, msg.panes, func(m *Model) tea.Cmd {
+			return func() tea.Msg {
+				window, err := agent.TileTargets(ids)
+				return tiledMsg{window: window, err: err}
+			}
+		})
+		return m, nil
+
+	case tiledMsg:
+		if msg.window == "" {
+			m.setFlash(msg.err.Error(), true)
+			return m, nil
+		}
+		if msg.err != nil {
+			// Some panes stayed where they were; stay to say which.
+			m.setFlash(msg.err.Error()+"; the rest are in "+msg.window, true)
+			return m, nil
+		}
+		if m.printTarget {
+			m.selected = msg.window
+		} else {
+			_ = agent.SwitchToPane(msg.window)
+		}
+		m.saveState()
+		m.restoreTitle()
+		return m, tea.Quit
+
 	case paneKilledMsg:
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
+		m.lastKilled = &msg.pane
+		if msg.note != "" {
+			m.setFlash(msg.note, false)
+		}
 		return m, loadPanes
 
 	case tea.MouseMsg:
@@ -370,7 +980,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if !m.previewVisible() {
+		return m, nil // no separator to drag
+	}
+	listRight := config.Get().ListOnRight
 	sep := m.listWidth()
+	if listRight {
+		sep = m.previewWidth()
+	}
 	switch msg.Action {
 	case tea.MouseActionPress:
 		if msg.Button == tea.MouseButtonLeft && msg.X >= sep-1 && msg.X <= sep+1 {
@@ -378,7 +995,11 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		}
 	case tea.MouseActionMotion:
 		if m.dragging {
-			w := max(min(msg.X, m.width-20), 20)
+			x := msg.X
+			if listRight {
+				x = m.width - msg.X - 1
+			}
+			w := max(min(x, m.width-20), 20)
 			m.sidebarWidth = w
 			m.preview.Width = m.previewWidth()
 		}
@@ -389,8 +1010,55 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.input != nil {
+		return m.handleInputKey(msg)
+	}
+	if m.confirm != nil {
+		return m.handleConfirmKey(msg)
+	}
+	if m.batch != nil {
+		return m.handleBatchKey(msg)
+	}
+	if m.picker != nil {
+		return m.handlePickerKey(msg)
+	}
+	if m.jump != nil {
+		return m.handleJumpKey(msg)
+	}
 	key := msg.String()
 
+	// shift+arrows browse other panes' previews without moving the cursor;
+	// any other key returns the preview to the selection (esc only that).
+	switch key {
+	case "shift+down", "shift+up":
+		from := m.peekIndex()
+		if from < 0 {
+			from = m.cursor
+		}
+		step := NextPane
+		if key == "shift+up" {
+			step = PrevPane
+		}
+		// Recent projects have no output to peek at.
+		next := step(m.items, from)
+		for next != from && m.items[next].PaneID == "" {
+			next = step(m.items, next)
+		}
+		if next >= 0 && next < len(m.items) && m.items[next].PaneID != "" {
+			m.peekPane = m.items[next].PaneID
+		}
+		return m, m.newPreviewCmd()
+	}
+	if m.peekPane != "" {
+		m.peekPane = ""
+		back := m.newPreviewCmd()
+		if key == "esc" {
+			return m, back
+		}
+		next, cmd := m.handleKey(msg)
+		return next, tea.Batch(back, cmd)
+	}
+
 	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
 		if m.count > 0 || key[0] != '0' {
 			m.count = m.count*10 + int(key[0]-'0')
@@ -400,28 +1068,22 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	count := max(m.count, 1)
 	m.count = 0
 
-	if key == "d" {
-		if m.pendingD {
-			m.pendingD = false
-			m.pendingG = false
+	if action, consumed := m.chords.feed(key, time.Now()); consumed {
+		switch action {
+		case chordKill:
 			return m, m.killCurrentPane()
-		}
-		m.pendingD = true
-		m.pendingG = false
-		return m, nil
-	}
-	m.pendingD = false
-
-	if key == "g" {
-		if m.pendingG {
-			m.pendingG = false
+		case chordFirst:
 			m.cursor = FirstPane(m.items)
 			return m, m.newPreviewCmd()
+		case chordToggleCollapse:
+			return m, m.toggleCollapse()
+		case chordCollapseAll:
+			return m, m.setAllCollapsed(true)
+		case chordExpandAll:
+			return m, m.setAllCollapsed(false)
 		}
-		m.pendingG = true
 		return m, nil
 	}
-	m.pendingG = false
 
 	switch key {
 	case "?":
@@ -432,12 +1094,24 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.cursor = LastPane(m.items)
 		return m, m.newPreviewCmd()
 
+	case "}":
+		for range count {
+			m.cursor = NextWorkspacePane(m.items, m.cursor)
+		}
+		return m, m.newPreviewCmd()
+
+	case "{":
+		for range count {
+			m.cursor = PrevWorkspacePane(m.items, m.cursor)
+		}
+		return m, m.newPreviewCmd()
+
 	case " ":
 		if p := m.resolvePane(m.cursor); p != nil {
 			switch p.Status {
 			case agent.StatusIdle:
 				p.Status = agent.StatusUnread
-			case agent.StatusNeedsAttention, agent.StatusUnread:
+			case agent.StatusNeedsAttention, agent.StatusUnread, agent.StatusNeedsAuth:
 				p.Status = agent.StatusIdle
 			default:
 				return m, nil
@@ -464,58 +1138,348 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "R":
-		agent.RestartWatch()
-		return m, loadPanes
-
-	case "H":
-		w := max(m.listWidth()-2*count, 20)
-		m.sidebarWidth = w
-		m.preview.Width = m.previewWidth()
+	case "n":
+		if p := m.resolvePane(m.cursor); p != nil {
+			key := agent.NoteKey(p)
+			m.input = &inputLine{
+				prompt: "note: ",
+				value:  []rune(m.notes[key]),
+				onSubmit: func(m *Model, value string) tea.Cmd {
+					if value = strings.TrimSpace(value); value == "" {
+						delete(m.notes, key)
+					} else {
+						m.notes[key] = value
+					}
+					_ = agent.SaveNotes(m.notes)
+					return nil
+				},
+			}
+		}
 		return m, nil
 
-	case "L":
-		w := min(m.listWidth()+2*count, m.width-20)
-		m.sidebarWidth = w
-		m.preview.Width = m.previewWidth()
+	case "p":
+		return m, m.openSnippetPicker()
+
+	case "i":
+		return m, m.openKeyPicker()
+
+	case "B":
+		m.startBroadcast()
 		return m, nil
 
-	case "j", "down":
-		for range count {
-			next := NextPane(m.items, m.cursor)
-			if next == m.cursor {
-				break
-			}
-			m.cursor = next
+	case "a":
+		m.showOthers = !m.showOthers
+		if !m.showOthers {
+			m.others = nil
+			m.rebuildItems()
+			m.cursor = NearestPane(m.items, m.cursor)
+			return m, nil
 		}
-		return m, m.newPreviewCmd()
+		return m, loadOtherPanes
 
-	case "k", "up":
-		for range count {
-			prev := PrevPane(m.items, m.cursor)
-			if prev == m.cursor {
+	case "tab":
+		if m.pickerMode {
+			return m, nil
+		}
+		m.previewHidden = !m.previewHidden
+		if m.previewHidden {
+			return m, nil
+		}
+		// Captures paused while hidden (unless peeking); reload the
+		// current selection.
+		m.previewFor = ""
+		return m, m.newPreviewCmd()
+
+	case "U":
+		m.relaunchKilled()
+		return m, nil
+
+	case "T":
+		return m, m.tileAttention()
+
+	case "!":
+		return m, m.pingPane()
+
+	case "X":
+		return m, m.stopCurrentPane()
+
+	case "F":
+		m.flat = !m.flat
+		m.rebuildItems()
+		m.cursor = NearestPane(m.items, m.cursor)
+		return m, m.newPreviewCmd()
+
+	case "S":
+		if m.onlySession != "" {
+			m.onlySession = ""
+			m.setFlash("showing all sessions", false)
+		} else if name, err := agent.CurrentSession(); err != nil {
+			m.setFlash(err.Error(), true)
+		} else {
+			m.onlySession = name
+			m.setFlash("showing session "+name+" only", false)
+		}
+		m.rebuildItems()
+		m.cursor = NearestPane(m.items, m.cursor)
+		return m, m.newPreviewCmd()
+
+	case "o":
+		p := m.resolvePane(m.cursor)
+		if p == nil {
+			return m, nil
+		}
+		if m.popups {
+			target := p.Target
+			return m, func() tea.Msg {
+				if err := agent.PopupPane(target); err != nil {
+					return flashMsg{err: err}
+				}
+				return nil
+			}
+		}
+		// No popups before tmux 3.2: switch to the pane instead.
+		return m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+
+	case "y":
+		if p := m.resolvePane(m.cursor); p != nil {
+			return m, copySwitchCmd(p.Target)
+		}
+		return m, nil
+
+	case "W":
+		m.startWorktreeAgent()
+		return m, nil
+
+	case "f":
+		m.startJump()
+		return m, nil
+
+	case "P":
+		m.peek = !m.peek
+		if !m.peek || m.previewVisible() {
+			return m, nil
+		}
+		// Nothing is captured while the preview is hidden; start now.
+		m.previewFor = ""
+		return m, m.newPreviewCmd()
+
+	case "K":
+		m.moveWorkspace(-1)
+		return m, nil
+
+	case "J":
+		m.moveWorkspace(1)
+		return m, nil
+
+	case "R":
+		agent.RestartWatch()
+		return m, loadPanes
+
+	case "H":
+		w := max(m.listWidth()-2*count, 20)
+		m.sidebarWidth = w
+		m.preview.Width = m.previewWidth()
+		return m, nil
+
+	case "L":
+		w := min(m.listWidth()+2*count, m.width-20)
+		m.sidebarWidth = w
+		m.preview.Width = m.previewWidth()
+		return m, nil
+
+	case "j", "down":
+		for range count {
+			next := NextPane(m.items, m.cursor)
+			if next == m.cursor {
+				break
+			}
+			m.cursor = next
+		}
+		return m, m.newPreviewCmd()
+
+	case "k", "up":
+		for range count {
+			prev := PrevPane(m.items, m.cursor)
+			if prev == m.cursor {
 				break
 			}
 			m.cursor = prev
 		}
 		return m, m.newPreviewCmd()
 
-	case "enter", "q", "esc", "ctrl+c":
-		if key == "enter" {
+	case "enter", "alt+enter", "q", "esc", "ctrl+c":
+		switching := key == "enter" || key == "alt+enter"
+		if !switching && config.Get().ConfirmQuitWhenActive {
+			if n := m.activeCount(); n > 0 {
+				text := fmt.Sprintf("%d agents active — quit anyway?", n)
+				if n == 1 {
+					text = "1 agent active — quit anyway?"
+				}
+				m.confirm = &confirmPrompt{
+					text: text,
+					onYes: func(m *Model) tea.Cmd {
+						m.saveState()
+						m.restoreTitle()
+						return tea.Quit
+					},
+				}
+				return m, nil
+			}
+		}
+		if switching && m.cursor >= 0 && m.cursor < len(m.items) {
+			if r := m.recentAt(m.items[m.cursor]); r != nil {
+				return m.resumeRecent(*r)
+			}
+		}
+		if switching {
 			if p := m.resolvePane(m.cursor); p != nil {
 				if p.Status == agent.StatusUnread && !m.reconciler.HasOverride(p.PaneID) {
 					p.Status = agent.StatusIdle
 					m.reconciler.SetOverride(p.PaneID, agent.StatusIdle, p.ContentHash)
 				}
-				_ = agent.SwitchToPane(p.Target)
+				switch {
+				case m.printTarget:
+					m.selected = p.Target
+				default:
+					// Leave a working agent's screen alone; the redraw
+					// would land in the middle of its output.
+					if key == "alt+enter" && p.Status != agent.StatusBusy {
+						_ = agent.ClearHistory(p.Target)
+					}
+					_ = agent.SwitchToPane(p.Target)
+				}
 			}
 		}
 		m.saveState()
+		m.restoreTitle()
 		return m, tea.Quit
 	}
 	return m, nil
 }
 
+// Selected returns the target of the pane chosen with enter when running with
+// Options.PrintTarget, or "" if the user quit without choosing.
+func (m Model) Selected() string {
+	return m.selected
+}
+
+// toggleCollapse collapses the group under the cursor, or expands it when the
+// cursor is on a collapsed header.
+func (m *Model) toggleCollapse() tea.Cmd {
+	if m.cursor < 0 || m.cursor >= len(m.items) {
+		return nil
+	}
+	group := m.items[m.cursor].Group
+	if group == "" {
+		return nil
+	}
+	delete(m.autoExpanded, group)
+	m.collapsed[group] = !m.collapsed[group]
+	if !m.collapsed[group] {
+		delete(m.collapsed, group)
+	}
+	m.rebuildItems()
+	m.cursor = m.findGroup(group)
+	return m.newPreviewCmd()
+}
+
+// setAllCollapsed collapses or expands every group, keeping the cursor on the
+// group it was in.
+func (m *Model) setAllCollapsed(collapsed bool) tea.Cmd {
+	group := ""
+	if m.cursor >= 0 && m.cursor < len(m.items) {
+		group = m.items[m.cursor].Group
+	}
+	clear(m.collapsed)
+	clear(m.autoExpanded)
+	if collapsed {
+		for _, it := range m.items {
+			if it.Group != "" {
+				m.collapsed[it.Group] = true
+			}
+		}
+	}
+	m.rebuildItems()
+	if idx := m.findGroup(group); idx >= 0 {
+		m.cursor = idx
+	} else {
+		m.cursor = NearestPane(m.items, m.cursor)
+	}
+	return m.newPreviewCmd()
+}
+
+// moveWorkspace swaps the workspace under the cursor with its neighbour in
+// direction delta (-1 up, 1 down) within the same section, and saves the
+// resulting order.
+func (m *Model) moveWorkspace(delta int) {
+	if m.cursor < 0 || m.cursor >= len(m.items) {
+		return
+	}
+	cur := m.items[m.cursor]
+	if cur.Group == "" {
+		return
+	}
+	stashed := strings.HasPrefix(cur.Group, "stashed/")
+
+	// Workspace keys in display order, and the index of the cursor's.
+	var keys []string
+	idx := -1
+	for _, it := range m.items {
+		if it.Kind != KindWorkspace && it.Kind != KindProjectGroup {
+			continue
+		}
+		if strings.HasPrefix(it.Group, "stashed/") != stashed {
+			continue
+		}
+		p := m.panes[it.PaneID]
+		if p == nil {
+			continue
+		}
+		if it.Group == cur.Group {
+			idx = len(keys)
+		}
+		keys = append(keys, m.workspaceKey(p))
+	}
+	to := idx + delta
+	if idx < 0 || to < 0 || to >= len(keys) {
+		return
+	}
+	keys[idx], keys[to] = keys[to], keys[idx]
+
+	// Keep saved positions of workspaces that aren't currently listed.
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		seen[k] = true
+	}
+	order := keys
+	for _, k := range m.state.WorkspaceOrder {
+		if !seen[k] {
+			order = append(order, k)
+		}
+	}
+	m.state.WorkspaceOrder = order
+
+	m.rebuildItems()
+	if cur.Kind == KindPane {
+		m.cursor = m.findPaneByID(cur.PaneID)
+	} else {
+		m.cursor = m.findGroup(cur.Group)
+	}
+	m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.bodyHeight())
+	m.saveState()
+}
+
+// findGroup returns the first selectable item of group: its header when
+// collapsed, otherwise its first pane. Returns -1 if the group is gone.
+func (m Model) findGroup(group string) int {
+	for i, it := range m.items {
+		if it.Group == group && it.selectable() {
+			return i
+		}
+	}
+	return -1
+}
+
 // clampCursorInSection keeps the cursor at the same index but ensures it stays
 // within the section the pane was originally in (wasStashed). Falls back to
 // other sections only if the original section has no panes left.
@@ -533,7 +1497,7 @@ func (m *Model) clampCursorInSection(idx int, wasStashed bool) {
 	// Section is empty, fall back to any pane.
 	if start >= end {
 		m.cursor = NearestPane(m.items, idx)
-		m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.height)
+		m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.bodyHeight())
 		return
 	}
 
@@ -547,23 +1511,23 @@ func (m *Model) clampCursorInSection(idx int, wasStashed bool) {
 
 	// Search for a pane within the section bounds.
 	for i := idx; i >= start; i-- {
-		if m.items[i].Kind == KindPane {
+		if m.items[i].selectable() {
 			m.cursor = i
-			m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.height)
+			m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.bodyHeight())
 			return
 		}
 	}
 	for i := idx + 1; i < end; i++ {
-		if m.items[i].Kind == KindPane {
+		if m.items[i].selectable() {
 			m.cursor = i
-			m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.height)
+			m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.bodyHeight())
 			return
 		}
 	}
 
 	// Section is empty, fall back to any pane.
 	m.cursor = NearestPane(m.items, idx)
-	m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.height)
+	m.scrollStart = VisibleSlice(len(m.items), m.cursor, m.bodyHeight())
 }
 
 // stashedSectionBounds returns the start and end indices of the stashed section.
@@ -586,7 +1550,7 @@ func (m *Model) saveState() {
 	m.reconciler.ApplyToCache(m.state.Panes)
 	cursor := m.cursor
 	scrollStart := m.scrollStart
-	if att := m.firstAttentionPane(); att >= 0 {
+	if att := m.firstPaneByPreference(); att >= 0 {
 		cursor = att
 		scrollStart = 0
 	}
@@ -602,9 +1566,56 @@ func (m *Model) saveState() {
 		ScrollStart: scrollStart,
 	}
 	m.state.SidebarWidth = m.sidebarWidth
+	m.state.Recent = agent.RememberRecent(m.state.Recent, paneList)
 	_ = agent.SaveState(m.state)
 }
 
+// attentionCount returns the number of non-stashed panes needing attention.
+func (m Model) attentionCount() int {
+	n := 0
+	for _, p := range m.panes {
+		if !p.Stashed && p.Status.WantsAttention() {
+			n++
+		}
+	}
+	return n
+}
+
+// activeCount returns the number of panes that are busy or need attention.
+func (m Model) activeCount() int {
+	n := 0
+	for _, p := range m.panes {
+		if p.Status == agent.StatusBusy || p.Status == agent.StatusNeedsAttention || p.Status == agent.StatusNeedsAuth {
+			n++
+		}
+	}
+	return n
+}
+
+// updateTitleBadge renames agent-mux's window when the attention count changes.
+func (m *Model) updateTitleBadge() {
+	if m.title.paneID == "" {
+		return
+	}
+	n := m.attentionCount()
+	if n == m.title.count {
+		return
+	}
+	m.title.count = n
+	if n == 0 {
+		// Renaming turned automatic-rename off; give it back.
+		_ = agent.RestoreWindowName(m.title.paneID, m.title.orig, m.title.autoRename)
+		return
+	}
+	_ = agent.RenameWindow(m.title.paneID, fmt.Sprintf("%s [%d!]", m.title.orig, n))
+}
+
+func (m *Model) restoreTitle() {
+	if m.title.paneID != "" && m.title.count > 0 {
+		_ = agent.RestoreWindowName(m.title.paneID, m.title.orig, m.title.autoRename)
+	}
+}
+
 // firstAttentionPane returns the index of the first non-stashed pane needing attention, or -1.
 func (m Model) firstAttentionPane() int {
 	for i, item := range m.items {
@@ -612,7 +1623,77 @@ func (m Model) firstAttentionPane() int {
 			continue
 		}
 		p := m.panes[item.PaneID]
-		if p != nil && !p.Stashed && (p.Status == agent.StatusNeedsAttention || p.Status == agent.StatusUnread) {
+		if p != nil && !p.Stashed && p.Status.WantsAttention() {
+			return i
+		}
+	}
+	return -1
+}
+
+// statusClass returns the status_priority name of s.
+func statusClass(s agent.PaneStatus) string {
+	switch {
+	case s.WantsAttention():
+		return "attention"
+	case s == agent.StatusBusy:
+		return "busy"
+	default:
+		return "idle"
+	}
+}
+
+// firstPaneByPreference returns the index of the pane the cursor starts on,
+// following startup_cursor, or -1 when there is none:
+//
+//   - "busy": the first non-stashed busy pane;
+//   - "recent": the non-stashed pane active most recently;
+//   - "top": the first pane of the list;
+//   - otherwise the first non-stashed pane of the highest status_priority
+//     class that has one, never an idle pane. Without status_priority that
+//     is the first pane needing attention.
+func (m Model) firstPaneByPreference() int {
+	cfg := config.Get()
+	switch cfg.StartupCursor {
+	case "busy":
+		return m.firstPaneOfClass("busy")
+	case "recent":
+		best := -1
+		var at time.Time
+		for i, item := range m.items {
+			if p := m.panes[item.PaneID]; item.Kind == KindPane && p != nil && !p.Stashed && p.LastActive.After(at) {
+				best, at = i, p.LastActive
+			}
+		}
+		return best
+	case "top":
+		if len(m.items) == 0 {
+			return -1
+		}
+		return NearestPane(m.items, 0)
+	}
+	order := cfg.StatusPriority
+	if len(order) == 0 {
+		return m.firstAttentionPane()
+	}
+	for _, class := range order {
+		if class == "idle" {
+			break
+		}
+		if i := m.firstPaneOfClass(class); i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// firstPaneOfClass returns the index of the first non-stashed pane whose
+// statusClass is class, or -1.
+func (m Model) firstPaneOfClass(class string) int {
+	for i, item := range m.items {
+		if item.Kind != KindPane {
+			continue
+		}
+		if p := m.panes[item.PaneID]; p != nil && !p.Stashed && statusClass(p.Status) == class {
 			return i
 		}
 	}
@@ -627,11 +1708,28 @@ func (m Model) View() string {
 		return errStyle.Render("Error: " + m.err.Error())
 	}
 	if len(m.items) == 0 {
-		return helpStyle.Render("No active sessions found.\nPress q to quit.")
+		return helpStyle.Render("No agents running. Start " + strings.Join(provider.All(), "/") +
+			" in a tmux pane.\nPress q to quit.")
 	}
 
 	listWidth := m.listWidth()
-	h := m.height
+	h := m.bodyHeight()
+
+	if !m.previewVisible() {
+		// Overlays that normally sit in the preview take the whole body.
+		var body string
+		switch {
+		case m.batch != nil:
+			body = m.batch.View(m.width, h)
+		case m.picker != nil:
+			body = m.picker.View(m.width)
+		case m.showHelp:
+			body = m.renderHelp()
+		default:
+			body = strings.Join(m.renderTree(m.width, h), "\n")
+		}
+		return lipgloss.NewStyle().Width(m.width).Height(h).Render(body) + "\n" + m.renderStatusBar(m.width)
+	}
 
 	treeLines := m.renderTree(listWidth, h)
 	listContent := strings.Join(treeLines, "\n")
@@ -641,29 +1739,163 @@ func (m Model) View() string {
 
 	pw := m.previewWidth()
 	var previewRendered string
-	if m.showHelp {
+	if m.batch != nil {
+		previewRendered = lipgloss.NewStyle().Width(pw).Height(h).Render(m.batch.View(pw, h))
+	} else if m.picker != nil {
+		previewRendered = lipgloss.NewStyle().Width(pw).Height(h).Render(m.picker.View(pw))
+	} else if m.showHelp {
 		previewRendered = lipgloss.NewStyle().Width(pw).Height(h).Render(m.renderHelp())
+	} else if q := m.secondPreviewPane(); q != nil {
+		// Wide terminal: the selection on the left, another pane on the
+		// right under a one-line header naming it.
+		w1 := (pw - 1) / 2
+		w2 := pw - 1 - w1
+		m.preview.Width, m.preview.Height = w1, h
+		m.second.Width, m.second.Height = w2, h-1
+		title := truncate(" "+q.Target+" · "+q.Provider, w2)
+		if m.secondFor != q.PaneID {
+			m.second.SetContent("")
+		}
+		right := dimStyle.Render(title+spaces(w2-dw(title))) + "\n" +
+			lipgloss.NewStyle().Width(w2).Height(h-1).Render(m.second.View())
+		previewRendered = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(w1).Height(h).Render(m.preview.View()), sep, right)
 	} else {
 		m.preview.Width = pw
 		m.preview.Height = h
 		previewRendered = lipgloss.NewStyle().Width(pw).Height(h).Render(m.preview.View())
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, listRendered, sep, previewRendered)
+	body := lipgloss.JoinHorizontal(lipgloss.Top, listRendered, sep, previewRendered)
+	if config.Get().ListOnRight {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, previewRendered, sep, listRendered)
+	}
+	return body + "\n" + m.renderStatusBar(m.width)
+}
+
+// renderStatusBar renders the bottom line: the selected pane's provider and
+// what it is doing on the left, its tmux target on the right.
+func (m Model) renderStatusBar(width int) string {
+	if m.input != nil {
+		return m.input.View(width)
+	}
+	if m.confirm != nil {
+		return m.confirm.View(width)
+	}
+	if m.flash != "" && time.Since(m.flashAt) < flashDuration {
+		style := statusBarStyle
+		if m.flashErr {
+			style = errStyle
+		}
+		text := " " + m.flash
+		return style.Render(truncate(text, width) + spaces(width-dw(text)))
+	}
+	p := m.resolvePane(m.cursor)
+	if m.peekPane != "" {
+		p = m.previewPane()
+	}
+	health, healthStyle := m.refreshAge()
+	if p == nil {
+		if dw(health) > width {
+			health = ""
+		}
+		return statusBarStyle.Render(spaces(width-dw(health))) + healthStyle.Render(health)
+	}
+	status := p.Status.String()
+	if p.StatusLabel != "" {
+		status = p.StatusLabel
+	}
+	if p.InMode {
+		status += " (copy mode, status paused)"
+	}
+	if p.ContextLow {
+		status += " · context low"
+	}
+	if n := m.duplicates[p.PaneID]; n > 0 {
+		status += fmt.Sprintf(" · %d %s agents in this directory", n, p.Provider)
+	}
+	left := " " + status
+	right := p.Target + " "
+	if m.peekPane != "" {
+		right = "peek " + right
+	}
+	name := ""
+	if p.Provider != "" {
+		name = " " + p.Provider
+		if p.Model != "" {
+			name += " (" + p.Model + ")"
+		}
+		if p.Container != "" {
+			name += " in " + p.Container
+		}
+		left = " ·" + left
+	}
+	if dw(name)+dw(left)+dw(right)+dw(health) > width {
+		right = ""
+	}
+	if dw(name)+dw(left)+dw(health) > width {
+		health = ""
+	}
+	gap := max(width-dw(name)-dw(left)-dw(right)-dw(health), 0)
+	return providerStyle(p.Provider, statusBarStyle).Render(name) +
+		statusBarStyle.Render(truncate(left, width-dw(name))+strings.Repeat(" ", gap)+right) +
+		healthStyle.Render(health)
+}
+
+// staleRefreshAfter is how long without a successful pane load before the
+// refresh age turns into a warning; a few missed polls, not one slow one.
+const staleRefreshAfter = 10 * time.Second
+
+// refreshAge returns the status bar's "↻ 3s" indicator of the time since the
+// last successful pane load, and its style: dim normally, a warning once
+// polling looks stuck (e.g. tmux hung).
+func (m Model) refreshAge() (string, lipgloss.Style) {
+	if m.lastRefresh.IsZero() {
+		return "", statusBarStyle
+	}
+	age := time.Since(m.lastRefresh)
+	text := fmt.Sprintf("↻ %ds ", int(age.Seconds()))
+	if age >= staleRefreshAfter {
+		return text, errStyle
+	}
+	return text, statusBarStyle
 }
 
 func (m Model) renderHelp() string {
 	keys := []struct{ key, desc string }{
 		{"j/k", "move down/up"},
 		{"[n]j/k", "move down/up n times"},
+		{"}/{", "next/previous workspace"},
 		{"enter", "switch to pane"},
+		{"alt+enter", "clear scrollback, switch"},
+		{"o", "view pane in a popup"},
 		{"space", "toggle attention"},
 		{"s/u", "stash/unstash"},
-		{"dd", "kill pane"},
-		{"gg", "go to first"},
+		{"n", "edit note"},
+		{"p", "send snippet"},
+		{"i", "send a key (up, ctrl+r, ...)"},
+		{"B", "broadcast to workspace"},
+		{"y", "copy switch command"},
+		{"W", "agent in new worktree"},
+		{"S", "current session only"},
+		{"a", "show non-agent panes"},
+		{"F", "flat list / by workspace"},
+		{"K/J", "move workspace up/down"},
+		{m.chords.keys(chordKill), "kill pane"},
+		{"X", "stop agent, then close pane"},
+		{"T", "tile attention panes in a new window"},
+		{"!", "ping: check the agent reacts"},
+		{"U", "relaunch killed agent"},
+		{"f", "jump to workspace"},
+		{"P", "peek output inline"},
+		{"S-up/dn", "preview other panes"},
+		{m.chords.keys(chordToggleCollapse), "collapse/expand workspace"},
+		{m.chords.keys(chordCollapseAll) + "/" + m.chords.keys(chordExpandAll), "collapse/expand all"},
+		{m.chords.keys(chordFirst), "go to first"},
 		{"G", "go to last"},
 		{"R", "reload watch"},
 		{"H/L", "resize sidebar"},
+		{"tab", "hide/show preview"},
 		{"?", "toggle help"},
 		{"q/esc", "quit"},
 	}
@@ -680,6 +1912,17 @@ func (m Model) renderHelp() string {
 	return b.String()
 }
 
+// previewVisible reports whether the preview is shown (and loaded).
+func (m Model) previewVisible() bool {
+	return !m.pickerMode && !m.previewHidden
+}
+
+// capturing reports whether the selected pane's output is loaded: for the
+// preview, or for the peek rows under the cursor while it is hidden.
+func (m Model) capturing() bool {
+	return m.previewVisible() || m.peek
+}
+
 func (m Model) listWidth() int {
 	if m.sidebarWidth > 0 {
 		return m.sidebarWidth
@@ -687,6 +1930,231 @@ func (m Model) listWidth() int {
 	return max(m.width*25/100, 20)
 }
 
+// flashDuration is how long a flash message stays in the status bar.
+const flashDuration = 3 * time.Second
+
+func (m *Model) setFlash(text string, isErr bool) {
+	m.flash = text
+	m.flashErr = isErr
+	m.flashAt = time.Now()
+}
+
+// startBroadcast prompts for text and, after confirmation, sends it to every
+// pane in the workspace under the cursor.
+func (m *Model) startBroadcast() {
+	if m.cursor < 0 || m.cursor >= len(m.items) || m.items[m.cursor].Group == "" {
+		return
+	}
+	panes := m.groupPanes(m.items[m.cursor].Group)
+	if len(panes) == 0 {
+		return
+	}
+	targets := make([]string, len(panes))
+	for i, p := range panes {
+		targets[i] = p.Target
+	}
+	m.input = &inputLine{
+		prompt: fmt.Sprintf("broadcast to %d panes: ", len(targets)),
+		onSubmit: func(m *Model, text string) tea.Cmd {
+			if strings.TrimSpace(text) == "" {
+				return nil
+			}
+			m.confirmBatch(fmt.Sprintf("Send %q to:", text), panes, func(m *Model) tea.Cmd {
+				return broadcastCmd(targets, text)
+			})
+			return nil
+		},
+	}
+}
+
+func broadcastCmd(targets []string, text string) tea.Cmd {
+	return func() tea.Msg {
+		sent := 0
+		var firstErr error
+		for _, t := range targets {
+			if err := agent.SendKeys(t, text); err != nil {
+				firstErr = cmp.Or(firstErr, err)
+				continue
+			}
+			sent++
+		}
+		if firstErr != nil {
+			return flashMsg{err: fmt.Errorf("sent to %d/%d panes: %w", sent, len(targets), firstErr)}
+		}
+		return flashMsg{text: fmt.Sprintf("sent to %d panes", sent)}
+	}
+}
+
+// startWorktreeAgent prompts for a branch name, then creates a worktree of the
+// selected pane's repository on that branch and starts the same provider in it.
+func (m *Model) startWorktreeAgent() {
+	p := m.resolvePane(m.cursor)
+	if p == nil {
+		return
+	}
+	if p.PathMissing {
+		m.setFlash(cmp.Or(p.ShortPath, p.Path)+" no longer exists", true)
+		return
+	}
+	repo, command := p.ProjectRoot, p.Provider
+	m.input = &inputLine{
+		prompt: "new worktree branch: ",
+		onSubmit: func(m *Model, branch string) tea.Cmd {
+			if strings.TrimSpace(branch) == "" {
+				return nil
+			}
+			return func() tea.Msg {
+				target, err := agent.NewWorktreeAgent(repo, branch, command)
+				if err != nil {
+					return flashMsg{err: err}
+				}
+				return flashMsg{text: "started " + command + " in " + target}
+			}
+		},
+	}
+}
+
+// copySwitchCmd copies the tmux command that switches to target.
+func copySwitchCmd(target string) tea.Cmd {
+	return func() tea.Msg {
+		command := agent.SwitchCommand(target)
+		if err := agent.CopyToClipboard(command); err != nil {
+			return flashMsg{err: err}
+		}
+		return flashMsg{text: "copied: " + command}
+	}
+}
+
+// openSnippetPicker lists the configured snippets; choosing one types it into
+// the selected pane.
+func (m *Model) openSnippetPicker() tea.Cmd {
+	p := m.resolvePane(m.cursor)
+	if p == nil {
+		return nil
+	}
+	snippets := config.Get().Snippets
+	if len(snippets) == 0 {
+		m.setFlash("no snippets configured", true)
+		return nil
+	}
+	names := slices.Sorted(maps.Keys(snippets))
+	items := make([]pickerItem, len(names))
+	for i, name := range names {
+		items[i] = pickerItem{label: name, detail: snippets[name]}
+	}
+	target := p.Target
+	m.picker = &picker{
+		title: "Send snippet to " + target,
+		items: items,
+		onSelect: func(m *Model, idx int) tea.Cmd {
+			name, text := names[idx], snippets[names[idx]]
+			return func() tea.Msg {
+				if err := agent.SendKeys(target, text); err != nil {
+					return flashMsg{err: err}
+				}
+				return flashMsg{text: "sent " + name + " to " + target}
+			}
+		},
+	}
+	return nil
+}
+
+// pingWait is how long pingPane gives the agent to echo the probe.
+const pingWait = 300 * time.Millisecond
+
+// pingPane checks that the selected pane's agent still reacts to input (see
+// agent.Ping) and reports the outcome in the status bar.
+func (m Model) pingPane() tea.Cmd {
+	p := m.resolvePane(m.cursor)
+	if p == nil {
+		return nil
+	}
+	target := p.Target
+	if p.Status == agent.StatusNeedsAttention || p.Status == agent.StatusNeedsAuth {
+		// A space could answer the prompt it is showing.
+		return func() tea.Msg {
+			return flashMsg{text: target + " is waiting on a prompt; not pinging"}
+		}
+	}
+	return func() tea.Msg {
+		alive, err := agent.Ping(target, pingWait)
+		switch {
+		case err != nil:
+			return flashMsg{err: err}
+		case alive:
+			return flashMsg{text: target + " responded to input"}
+		default:
+			return flashMsg{err: fmt.Errorf("%s did not react within %v", target, pingWait)}
+		}
+	}
+}
+
+// tileAttention asks to move every listed pane needing attention into one
+// new tiled window, naming the windows that close because all their panes
+// move, then switches there, quitting like enter does.
+func (m *Model) tileAttention() tea.Cmd {
+	var panes []*agent.Pane
+	var ids []string
+	for _, item := range m.items {
+		if p := m.panes[item.PaneID]; item.Kind == KindPane && p != nil && !p.Stashed && p.Status.WantsAttention() {
+			panes = append(panes, p)
+			ids = append(ids, p.PaneID)
+		}
+	}
+	if len(ids) == 0 {
+		m.setFlash("no panes need attention", true)
+		return nil
+	}
+	return func() tea.Msg {
+		closing, err := agent.ClosingWindows(ids)
+		return tileConfirmMsg{panes: panes, closing: closing, err: err}
+	}
+}
+
+// sendableKeys are the tmux keys offered by the key picker, with what they
+// usually do in an agent.
+var sendableKeys = []pickerItem{
+	{label: "Up", detail: "recall the previous prompt"},
+	{label: "Down", detail: "next prompt in history"},
+	{label: "Escape", detail: "cancel / interrupt"},
+	{label: "Enter", detail: "submit"},
+	{label: "BTab", detail: "shift+tab: cycle modes"},
+	{label: "Tab", detail: "complete"},
+	{label: "C-r", detail: "search history"},
+	{label: "C-c", detail: "interrupt"},
+	{label: "C-l", detail: "clear the screen"},
+}
+
+// openKeyPicker lists sendableKeys; choosing one presses it in the selected
+// pane. Headers have no pane and open nothing.
+func (m *Model) openKeyPicker() tea.Cmd {
+	p := m.resolvePane(m.cursor)
+	if p == nil {
+		return nil
+	}
+	target := p.Target
+	m.picker = &picker{
+		title: "Send key to " + target,
+		items: sendableKeys,
+		onSelect: func(m *Model, idx int) tea.Cmd {
+			key := sendableKeys[idx].label
+			return func() tea.Msg {
+				if err := agent.SendKey(target, key); err != nil {
+					return flashMsg{err: err}
+				}
+				return flashMsg{text: "sent " + key + " to " + target}
+			}
+		},
+	}
+	return nil
+}
+
+// bodyHeight is the height available to the tree and preview, leaving one
+// line for the status bar.
+func (m Model) bodyHeight() int {
+	return max(m.height-1, 1)
+}
+
 func (m Model) previewWidth() int {
 	return m.width - m.listWidth() - 1
 }
@@ -696,28 +2164,146 @@ func (m Model) renderTree(width, height int) []string {
 		return []string{"  No sessions"}
 	}
 
+	// Peek rows are drawn under the cursor row without being tree items, so
+	// navigation is unaffected; reserve room for them when scrolling.
+	peek := m.peekLines()
 	cursor := max(m.cursor, 0)
-	start := VisibleSlice(len(m.items), cursor, height)
-	end := min(start+height, len(m.items))
+	start := VisibleSlice(len(m.items), cursor, max(height-len(peek), 1))
+	end := min(start+height-len(peek), len(m.items))
 
-	lines := make([]string, 0, end-start)
+	lines := make([]string, 0, end-start+len(peek))
 	for i := start; i < end; i++ {
+		if m.jump != nil {
+			if label, ok := m.jump.labels[i]; ok {
+				lines = append(lines, jumpLabelStyle.Render(label)+m.renderTreeItem(m.items[i], false, width-dw(label)))
+				continue
+			}
+		}
 		lines = append(lines, m.renderTreeItem(m.items[i], i == cursor, width))
+		if i == cursor {
+			for _, l := range peek {
+				lines = append(lines, dimStyle.Render(truncate("      "+l, width)))
+			}
+		}
 	}
 	return lines
 }
 
+// peekLines returns the last two non-blank lines of the selected pane's
+// preview when peek is on, stripped of styling.
+func (m Model) peekLines() []string {
+	p := m.resolvePane(m.cursor)
+	if !m.peek || p == nil || p.PaneID != m.previewShown {
+		return nil
+	}
+	var out []string
+	lines := strings.Split(ansi.Strip(m.lastPreviewContent), "\n")
+	for i := len(lines) - 1; i >= 0 && len(out) < 2; i-- {
+		if l := strings.TrimSpace(lines[i]); l != "" {
+			out = append([]string{l}, out...)
+		}
+	}
+	return out
+}
+
 func (m Model) killCurrentPane() tea.Cmd {
 	p := m.resolvePane(m.cursor)
 	if p == nil {
 		return nil
 	}
-	target := p.Target
+	killed := *p
 	return func() tea.Msg {
-		return paneKilledMsg{err: agent.KillPane(target)}
+		return paneKilledMsg{pane: killed, err: agent.KillPane(killed.Target)}
+	}
+}
+
+// stopWait is how long X gives an agent to exit after its quit keys before
+// the pane is killed anyway.
+const stopWait = 5 * time.Second
+
+// stopCurrentPane makes the selected pane's agent exit with its quit keys
+// and then removes the pane, like the kill chord but letting the agent save
+// its session first.
+func (m *Model) stopCurrentPane() tea.Cmd {
+	p := m.resolvePane(m.cursor)
+	if p == nil {
+		return nil
+	}
+	stopped := *p
+	m.setFlash("stopping "+stopped.Provider+" in "+stopped.Target+"…", false)
+	return func() tea.Msg {
+		exited, err := agent.StopPane(stopped, stopWait)
+		note := stopped.Provider + " exited"
+		if !exited {
+			note = fmt.Sprintf("%s did not exit within %v; pane killed", stopped.Provider, stopWait)
+		}
+		return paneKilledMsg{pane: stopped, err: err, note: note}
+	}
+}
+
+// relaunchKilled asks to start the provider of the last killed pane again in
+// its directory and session. The pane's scrollback and conversation are gone;
+// this only saves retyping the command.
+func (m *Model) relaunchKilled() {
+	p := m.lastKilled
+	if p == nil {
+		m.setFlash("nothing to relaunch", false)
+		return
+	}
+	if p.PathMissing || dirGone(p.Path) {
+		m.setFlash(cmp.Or(p.ShortPath, p.Path)+" no longer exists", true)
+		return
+	}
+	m.confirm = &confirmPrompt{
+		text: fmt.Sprintf("relaunch %s in %s (%s)?", p.Provider, cmp.Or(p.ShortPath, p.Path), p.Session),
+		onYes: func(m *Model) tea.Cmd {
+			m.lastKilled = nil
+			return func() tea.Msg {
+				target, err := agent.NewAgentPane(p.Session, p.Path, p.Provider)
+				if err != nil {
+					return flashMsg{err: err}
+				}
+				return flashMsg{text: "relaunched " + p.Provider + " in " + target}
+			}
+		},
 	}
 }
 
+// showPreview puts content in the preview, scrolled to the bottom. While the
+// previewed pane needs attention, the line that asked is highlighted and
+// scrolled into view.
+func (m *Model) showPreview(content string) {
+	m.preview.SetContent(content)
+	m.preview.GotoBottom()
+	asked := highlightedLine(m.panes[m.previewShown])
+	if asked == "" {
+		return
+	}
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		plain := ansi.Strip(lines[i])
+		if strings.Join(strings.Fields(plain), " ") != asked {
+			continue
+		}
+		lines[i] = attentionLineStyle.Render(plain)
+		m.preview.SetContent(strings.Join(lines, "\n"))
+		m.preview.GotoBottom()
+		if i < m.preview.YOffset {
+			// Keep a little of what led up to the question in view.
+			m.preview.SetYOffset(max(i-2, 0))
+		}
+		return
+	}
+}
+
+// highlightedLine returns the line showPreview highlights for p, if any.
+func highlightedLine(p *agent.Pane) string {
+	if p == nil || p.Status != agent.StatusNeedsAttention {
+		return ""
+	}
+	return p.AttentionLine
+}
+
 func (m *Model) newPreviewCmd() tea.Cmd {
 	m.previewGen++
 	gen := m.previewGen
@@ -726,17 +2312,86 @@ func (m *Model) newPreviewCmd() tea.Cmd {
 	})
 }
 
+// previewPane returns the pane whose output the preview shows: the selected
+// pane (or the peeked one while browsing with shift+arrows), or the first
+// pane of a collapsed group under the cursor.
+func (m Model) previewPane() *agent.Pane {
+	idx := m.cursor
+	if i := m.peekIndex(); i >= 0 {
+		idx = i
+	}
+	if p := m.resolvePane(idx); p != nil {
+		return p
+	}
+	if idx >= 0 && idx < len(m.items) && m.items[idx].Collapsed {
+		return m.panes[m.items[idx].PaneID]
+	}
+	return nil
+}
+
+// peekIndex returns the index of the item previewed via shift+arrows: the
+// peeked pane's row, or the header of the collapsed group hiding it. It is -1
+// when not peeking or the pane is no longer listed.
+func (m Model) peekIndex() int {
+	if m.peekPane == "" {
+		return -1
+	}
+	for i, it := range m.items {
+		if it.selectable() && it.PaneID == m.peekPane {
+			return i
+		}
+	}
+	return -1
+}
+
 func (m Model) previewCmd() tea.Cmd {
-	p := m.resolvePane(m.cursor)
-	if p == nil {
+	p := m.previewPane()
+	if p == nil || !m.capturing() {
 		return nil
 	}
 	if p.PaneID == m.previewFor {
 		return nil
 	}
-	lines := m.height
-	if lines <= 0 {
+	lines := m.bodyHeight()
+	if m.height <= 0 {
 		lines = 50
 	}
-	return loadPreview(p.Target, p.PaneID, lines, m.previewGen)
+	cmd := loadPreview(p.Target, p.PaneID, lines, m.previewGen, false)
+	if q := m.secondPreviewPane(); q != nil {
+		return tea.Batch(cmd, loadPreview(q.Target, q.PaneID, lines, m.previewGen, true))
+	}
+	return cmd
+}
+
+// defaultSplitPreviewWidth is the terminal width from which the preview
+// splits into two columns, unless split_preview_width says otherwise.
+const defaultSplitPreviewWidth = 240
+
+// secondPreviewPane returns the pane shown in the right column of the split
+// preview: the first pane needing attention other than the previewed one,
+// else the next pane in the list. It is nil, and nothing extra is captured,
+// while the terminal is narrower than the split width or an overlay covers
+// the preview.
+func (m Model) secondPreviewPane() *agent.Pane {
+	minWidth := cmp.Or(config.Get().SplitPreviewWidth, defaultSplitPreviewWidth)
+	if minWidth < 0 || m.width < minWidth || !m.previewVisible() || m.picker != nil || m.batch != nil || m.showHelp {
+		return nil
+	}
+	first := m.previewPane()
+	if first == nil {
+		return nil
+	}
+	if att := m.firstAttentionPane(); att >= 0 {
+		if p := m.panes[m.items[att].PaneID]; p != first {
+			return p
+		}
+	}
+	idx := m.cursor
+	if i := m.peekIndex(); i >= 0 {
+		idx = i
+	}
+	if p := m.resolvePane(NextPane(m.items, idx)); p != nil && p != first {
+		return p
+	}
+	return nil
 }
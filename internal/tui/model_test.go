@@ -0,0 +1,587 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/leo/agent-mux/internal/agent"
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// TestMain keeps the user's config and state files out of the tests, and
+// any tmux the code under test reaches for fails at once.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "agent-mux-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	os.Setenv("HOME", dir)
+	os.Setenv("AGENTMUX_TMUX", "false")
+	os.Unsetenv("TMUX_PANE")
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testModel returns a model listing panes, as NewModel would after the first
+// refresh, with the cursor on the first pane.
+func testModel(panes ...agent.Pane) Model {
+	m := Model{
+		preview:      viewport.New(80, 20),
+		second:       viewport.New(80, 20),
+		panes:        make(map[string]*agent.Pane),
+		reconciler:   agent.NewReconciler(),
+		notes:        make(map[string]string),
+		collapsed:    make(map[string]bool),
+		autoExpanded: make(map[string]bool),
+		completed:    make(map[string]time.Time),
+		width:        120,
+		height:       30,
+		loaded:       true,
+	}
+	for i := range panes {
+		p := panes[i]
+		if p.ProjectRoot == "" {
+			p.ProjectRoot = p.Path
+		}
+		m.panes[p.PaneID] = &p
+	}
+	m.rebuildItems()
+	m.cursor = FirstPane(m.items)
+	return m
+}
+
+// update feeds msg to m and returns the updated model.
+func update(m Model, msg any) Model {
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+func TestPeekSurvivesPreviewReload(t *testing.T) {
+	m := testModel(agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/app"})
+	m.peek = true
+	m = update(m, previewLoadedMsg{paneID: "%1", content: "one\n\ntwo\nthree\n", gen: m.previewGen})
+	want := []string{"two", "three"}
+	if got := m.peekLines(); !slices.Equal(got, want) {
+		t.Fatalf("peekLines = %q, want %q", got, want)
+	}
+	// Every tick drops previewFor to force a fresh capture.
+	m = update(m, previewTickMsg{gen: m.previewGen})
+	if got := m.peekLines(); !slices.Equal(got, want) {
+		t.Errorf("after a preview tick, peekLines = %q, want %q", got, want)
+	}
+}
+
+func TestPeekCapturesWithPreviewHidden(t *testing.T) {
+	m := testModel(agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/app"})
+	m.previewHidden = true
+	if m.previewCmd() != nil {
+		t.Fatal("previewCmd captures with the preview hidden and peek off")
+	}
+	m.peek = true
+	if m.previewCmd() == nil {
+		t.Error("previewCmd = nil with peek on, want a capture for the peek rows")
+	}
+}
+
+func TestPreviewUpdatesOnSwitchToPaneWithSameContent(t *testing.T) {
+	m := testModel(
+		agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/app"},
+		agent.Pane{PaneID: "%2", Target: "main:2.0", Path: "/src/app"},
+	)
+	content := strings.Repeat("same output\n", 50)
+	m = update(m, previewLoadedMsg{paneID: "%1", content: content, gen: m.previewGen})
+	m.preview.SetYOffset(0) // scrolled up to read the first pane's history
+
+	m.cursor = NextPane(m.items, m.cursor)
+	m.newPreviewCmd()
+	m = update(m, previewLoadedMsg{paneID: "%2", content: content, gen: m.previewGen})
+	if m.previewShown != "%2" {
+		t.Errorf("previewShown = %q, want %%2", m.previewShown)
+	}
+	if !m.preview.AtBottom() {
+		t.Errorf("preview kept the first pane's scroll position (offset %d)", m.preview.YOffset)
+	}
+
+	// The same pane's unchanged content leaves the scroll position alone.
+	m.preview.SetYOffset(3)
+	m = update(m, previewLoadedMsg{paneID: "%2", content: content, gen: m.previewGen})
+	if m.preview.YOffset != 3 {
+		t.Errorf("unchanged content reset the scroll position to %d", m.preview.YOffset)
+	}
+}
+
+// useConfig makes cfg the loaded config for the rest of the test.
+func useConfig(t *testing.T, cfg config.Config) {
+	t.Helper()
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+}
+
+// outline lists m's items one per line: headers by what they group, panes
+// indented by id.
+func outline(m Model) []string {
+	var out []string
+	for _, it := range m.items {
+		p := m.panes[it.PaneID]
+		switch it.Kind {
+		case KindWorkspace:
+			out = append(out, "workspace "+cmp.Or(it.HeaderTitle, p.Path))
+		case KindProjectGroup:
+			out = append(out, "project "+p.ProjectRoot)
+		case KindPane:
+			out = append(out, "  "+it.PaneID)
+		case KindSectionHeader:
+			out = append(out, "section "+it.HeaderTitle)
+		}
+	}
+	return out
+}
+
+func TestGroupTreeByKey(t *testing.T) {
+	// Panes as EnrichPanes leaves them: with group_by "repo", the two
+	// subdirectories of /src/mono share its root.
+	panes := func(repo bool) []agent.Pane {
+		root := func(path string) string {
+			if repo && strings.HasPrefix(path, "/src/mono/") {
+				return "/src/mono"
+			}
+			return path
+		}
+		var ps []agent.Pane
+		// In tmux order: by session, then window.
+		for i, p := range []struct{ session, path string }{
+			{"work", "/src/mono/api"},
+			{"work", "/src/mono/web"},
+			{"play", "/src/mono/web"},
+			{"play", "/src/site"},
+		} {
+			id := fmt.Sprintf("%%%d", i+1)
+			ps = append(ps, agent.Pane{PaneID: id, Target: fmt.Sprintf("%s:%d.0", p.session, i), Session: p.session,
+				Path: p.path, ProjectRoot: root(p.path), Order: i})
+		}
+		return ps
+	}
+	tests := []struct {
+		groupBy string
+		want    []string
+	}{
+		{"path", []string{
+			"workspace /src/mono/api", "  %1",
+			"workspace /src/mono/web", "  %2", "  %3",
+			"workspace /src/site", "  %4",
+		}},
+		{"repo", []string{
+			"project /src/mono", "  %1", "  %2", "  %3",
+			"workspace /src/site", "  %4",
+		}},
+		{"session", []string{
+			"workspace work", "  %1", "  %2",
+			"workspace play", "  %3", "  %4",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			useConfig(t, config.Config{GroupBy: tt.groupBy})
+			m := testModel(panes(tt.groupBy == "repo")...)
+			if got := outline(m); !slices.Equal(got, tt.want) {
+				t.Errorf("items:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPriorityPathsSortFirst(t *testing.T) {
+	var panes []agent.Pane
+	for i, path := range []string{"/src/a", "/src/work/api", "/src/b", "/src/work/web"} {
+		panes = append(panes, agent.Pane{PaneID: fmt.Sprintf("%%%d", i+1), Target: fmt.Sprintf("main:%d.0", i),
+			Session: "main", Path: path, Order: i})
+	}
+	tests := []struct {
+		name     string
+		priority []string
+		order    []string // manual workspace order
+		want     []string
+	}{
+		{"no priority paths", nil, nil, []string{
+			"workspace /src/a", "  %1",
+			"workspace /src/work/api", "  %2",
+			"workspace /src/b", "  %3",
+			"workspace /src/work/web", "  %4",
+		}},
+		{"priority tier first, tmux order within", []string{"/src/work/*"}, nil, []string{
+			"workspace /src/work/api", "  %2",
+			"workspace /src/work/web", "  %4",
+			"workspace /src/a", "  %1",
+			"workspace /src/b", "  %3",
+		}},
+		{"manual order within each tier", []string{"/src/work/*"}, []string{"/src/b", "/src/work/web"}, []string{
+			"workspace /src/work/web", "  %4",
+			"workspace /src/work/api", "  %2",
+			"workspace /src/b", "  %3",
+			"workspace /src/a", "  %1",
+		}},
+		{"manual order doesn't lift a workspace out of its tier", []string{"/src/b"}, []string{"/src/a"}, []string{
+			"workspace /src/b", "  %3",
+			"workspace /src/a", "  %1",
+			"workspace /src/work/api", "  %2",
+			"workspace /src/work/web", "  %4",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, config.Config{PriorityPaths: tt.priority})
+			m := testModel(panes...)
+			m.state.WorkspaceOrder = tt.order
+			m.rebuildItems()
+			if got := outline(m); !slices.Equal(got, tt.want) {
+				t.Errorf("items:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestStatusPriority(t *testing.T) {
+	var panes []agent.Pane
+	for i, status := range []agent.PaneStatus{agent.StatusIdle, agent.StatusBusy, agent.StatusNeedsAttention, agent.StatusUnread} {
+		panes = append(panes, agent.Pane{PaneID: fmt.Sprintf("%%%d", i+1), Target: fmt.Sprintf("main:%d.0", i),
+			Session: "main", Path: "/src/app", Order: i, Status: status})
+	}
+	tests := []struct {
+		name  string
+		order []string
+		want  []string
+		first string // the pane the cursor starts on, "" for none
+	}{
+		{"tmux order by default", nil,
+			[]string{"workspace /src/app", "  %1", "  %2", "  %3", "  %4"}, "%3"},
+		{"attention first", []string{"attention", "busy", "idle"},
+			[]string{"workspace /src/app", "  %3", "  %4", "  %2", "  %1"}, "%3"},
+		{"busy first", []string{"busy", "attention", "idle"},
+			[]string{"workspace /src/app", "  %2", "  %3", "  %4", "  %1"}, "%2"},
+		{"idle first never starts on an idle pane", []string{"idle", "busy", "attention"},
+			[]string{"workspace /src/app", "  %1", "  %2", "  %3", "  %4"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, config.Config{StatusPriority: tt.order})
+			m := testModel(panes...)
+			if got := outline(m); !slices.Equal(got, tt.want) {
+				t.Errorf("items:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			first := ""
+			if i := m.firstPaneByPreference(); i >= 0 {
+				first = m.items[i].PaneID
+			}
+			if first != tt.first {
+				t.Errorf("starts on %q, want %q", first, tt.first)
+			}
+		})
+	}
+}
+
+func TestPingSkipsPanesAtAPrompt(t *testing.T) {
+	m := testModel(agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/app", Status: agent.StatusNeedsAttention})
+	msg, ok := m.pingPane()().(flashMsg)
+	if !ok || msg.err != nil || !strings.Contains(msg.text, "not pinging") {
+		t.Errorf("pingPane on a prompt = %+v, want a note that it was skipped", msg)
+	}
+}
+
+func TestDirectoryActionsOnMissingPath(t *testing.T) {
+	gone := filepath.Join(t.TempDir(), "deleted")
+	p := agent.Pane{PaneID: "%1", Target: "main:1.0", Session: "main", Path: gone, ShortPath: "~/deleted",
+		Provider: "claude", PathMissing: true}
+	m := testModel(p)
+	if row := m.renderTreeItem(m.items[0], false, 60); !strings.Contains(ansi.Strip(row), "(missing)") {
+		t.Errorf("workspace header %q doesn't mark the path missing", ansi.Strip(row))
+	}
+
+	wantFlash := func(t *testing.T, m Model) {
+		t.Helper()
+		if m.flash != "~/deleted no longer exists" || !m.flashErr {
+			t.Errorf("flash = %q (error %v), want the missing directory reported", m.flash, m.flashErr)
+		}
+		if m.input != nil || m.confirm != nil {
+			t.Error("prompted for an action on a missing directory")
+		}
+	}
+	t.Run("worktree", func(t *testing.T) {
+		m := m
+		m.startWorktreeAgent()
+		wantFlash(t, m)
+	})
+	t.Run("relaunch", func(t *testing.T) {
+		m := m
+		// Deleted after the pane was killed: PathMissing wasn't set then.
+		killed := p
+		killed.PathMissing = false
+		m.lastKilled = &killed
+		m.relaunchKilled()
+		wantFlash(t, m)
+	})
+	t.Run("resume", func(t *testing.T) {
+		next, cmd := m.resumeRecent(agent.RecentProject{Path: gone, ShortPath: "~/deleted", Provider: "claude"})
+		if cmd != nil {
+			t.Error("resumeRecent returned a command for a missing directory")
+		}
+		wantFlash(t, next.(Model))
+	})
+}
+
+func TestPeekFollowsPaneAcrossRefresh(t *testing.T) {
+	panes := []agent.Pane{
+		{PaneID: "%1", Target: "main:1.0", Path: "/src/a", Order: 1},
+		{PaneID: "%2", Target: "main:2.0", Path: "/src/b", Order: 2},
+		{PaneID: "%3", Target: "main:3.0", Path: "/src/c", Order: 3},
+	}
+	m := testModel(panes...)
+	m = update(m, tea.KeyMsg{Type: tea.KeyShiftDown})
+	if p := m.previewPane(); p == nil || p.PaneID != "%2" {
+		t.Fatalf("peeked %v, want %%2", p)
+	}
+
+	// A new pane listed above the peeked one moves it down a row.
+	refreshed := append([]agent.Pane{{PaneID: "%4", Target: "main:0.0", Path: "/src/0", Order: 0}}, panes...)
+	for i := range refreshed {
+		refreshed[i].ProjectRoot = refreshed[i].Path
+	}
+	m = update(m, panesLoadedMsg{panes: refreshed})
+	if p := m.previewPane(); p == nil || p.PaneID != "%2" {
+		t.Errorf("after a refresh the preview shows %v, want the peeked %%2", p)
+	}
+
+	// It ends when the peeked pane goes away.
+	m = update(m, panesLoadedMsg{panes: refreshed[:2]})
+	if m.peekPane != "" {
+		t.Errorf("peekPane = %q after the pane closed, want the cursor followed", m.peekPane)
+	}
+}
+
+func TestTileAttentionConfirmsClosingWindows(t *testing.T) {
+	m := testModel(
+		agent.Pane{PaneID: "%1", Target: "main:1.0", Session: "main", Window: "1", Path: "/src/a", Status: agent.StatusNeedsAttention},
+		agent.Pane{PaneID: "%2", Target: "main:2.1", Session: "main", Window: "2", Path: "/src/b", Status: agent.StatusNeedsAttention},
+		agent.Pane{PaneID: "%3", Target: "main:3.0", Session: "main", Window: "3", Path: "/src/c"},
+	)
+	// The windows are looked up in the background, not in Update.
+	if cmd := m.tileAttention(); cmd == nil {
+		t.Fatal("tileAttention = nil, want a command listing the windows")
+	}
+	if m.batch != nil {
+		t.Fatal("asked to confirm before the windows were known")
+	}
+
+	m = update(m, tileConfirmMsg{panes: []*agent.Pane{m.panes["%1"], m.panes["%2"]}, closing: []string{"main:1"}})
+	if m.batch == nil || !strings.Contains(m.batch.title, "closing main:1") {
+		t.Fatalf("confirmation = %+v, want it to name the window that closes", m.batch)
+	}
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd == nil {
+		t.Fatal("confirming returned no command, want the tiling run in the background")
+	}
+	m = next.(Model)
+
+	// A partial tiling stays to report the panes left behind.
+	m = update(m, tiledMsg{window: "main:@9", err: fmt.Errorf("join-pane: could not move %%2")})
+	if !m.flashErr || !strings.Contains(m.flash, "could not move %2") {
+		t.Errorf("flash = %q, want the pane that didn't move", m.flash)
+	}
+}
+
+func TestConfirmQuitCountsAgents(t *testing.T) {
+	useConfig(t, config.Config{ConfirmQuitWhenActive: true})
+	busy := agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/a", Status: agent.StatusBusy}
+	other := agent.Pane{PaneID: "%2", Target: "main:2.0", Path: "/src/b", Status: agent.StatusNeedsAttention}
+	tests := []struct {
+		panes []agent.Pane
+		want  string
+	}{
+		{[]agent.Pane{busy}, "1 agent active — quit anyway?"},
+		{[]agent.Pane{busy, other}, "2 agents active — quit anyway?"},
+	}
+	for _, tt := range tests {
+		m := update(testModel(tt.panes...), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		if m.confirm == nil || m.confirm.text != tt.want {
+			t.Errorf("with %d active: confirm = %+v, want %q", len(tt.panes), m.confirm, tt.want)
+		}
+	}
+}
+
+// logTmux points agent-mux's tmux at a script that records each call's
+// arguments, one line per call, and returns a function reading them back.
+func logTmux(t *testing.T) func() []string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "tmux")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$*\" >> "+log+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AGENTMUX_TMUX", script)
+	return func() []string {
+		out, _ := os.ReadFile(log)
+		return strings.Split(strings.TrimSpace(string(out)), "\n")
+	}
+}
+
+func TestTitleBadgeRestoresAutomaticRename(t *testing.T) {
+	calls := logTmux(t)
+	m := testModel(agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/a", Status: agent.StatusNeedsAttention})
+	m.title = windowTitle{paneID: "%9", orig: "zsh", autoRename: true}
+	m.updateTitleBadge()
+	m.panes["%1"].Status = agent.StatusIdle
+	m.updateTitleBadge()
+	want := []string{"rename-window -t %9 zsh [1!]", "set-option -w -t %9 automatic-rename on"}
+	if got := calls(); !slices.Equal(got, want) {
+		t.Errorf("tmux calls = %q, want %q", got, want)
+	}
+}
+
+func TestHeaderCount(t *testing.T) {
+	m := testModel(
+		agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/a", Status: agent.StatusNeedsAttention},
+		agent.Pane{PaneID: "%2", Target: "main:2.0", Path: "/src/a"},
+		agent.Pane{PaneID: "%3", Target: "main:3.0", Path: "/src/b", Status: agent.StatusNeedsAttention},
+	)
+	counts := func() []string {
+		var out []string
+		for _, it := range m.items {
+			if it.Kind == KindWorkspace {
+				out = append(out, m.headerCount(it))
+			}
+		}
+		return out
+	}
+	if got, want := counts(), []string{"(2)", ""}; !slices.Equal(got, want) {
+		t.Errorf("header counts = %q, want %q", got, want)
+	}
+	for _, it := range m.items {
+		if it.Kind == KindWorkspace {
+			m.collapsed[it.Group] = true
+		}
+	}
+	m.rebuildItems()
+	if got, want := counts(), []string{"(2 · 1!)", "(1 · 1!)"}; !slices.Equal(got, want) {
+		t.Errorf("collapsed header counts = %q, want %q", got, want)
+	}
+}
+
+func TestPopupDoesNotAskTmuxVersion(t *testing.T) {
+	calls := logTmux(t)
+	m := testModel(agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/a"})
+	m.popups = true
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if cmd == nil {
+		t.Fatal("o returned no command, want the popup opened in the background")
+	}
+	if got := calls(); slices.Contains(got, "-V") {
+		t.Errorf("o ran tmux -V (%q); the version is checked once at startup", got)
+	}
+}
+
+func TestTrackCompletedDecays(t *testing.T) {
+	m := testModel(agent.Pane{PaneID: "%1", Target: "main:1.0", Path: "/src/app"})
+	done := map[string]*agent.Pane{"%1": {PaneID: "%1", HeuristicDone: true}}
+
+	m.trackCompleted(done, true)
+	if m.justCompleted(done["%1"]) {
+		t.Fatal("summary already on screen at startup flagged as just completed")
+	}
+
+	m.trackCompleted(done, false)
+	if !m.justCompleted(done["%1"]) {
+		t.Fatal("new completion summary not flagged")
+	}
+
+	m.completed["%1"] = time.Now().Add(-completedGlyphDuration)
+	if m.justCompleted(done["%1"]) {
+		t.Error("completion still flagged after the glyph duration")
+	}
+	m.panes = done
+	m.trackCompleted(done, false)
+	if _, ok := m.completed["%1"]; ok {
+		t.Error("expired completion not forgotten")
+	}
+
+	m.completed["%1"] = time.Now()
+	m.trackCompleted(map[string]*agent.Pane{}, false)
+	if _, ok := m.completed["%1"]; ok {
+		t.Error("completion of a vanished pane not forgotten")
+	}
+}
+
+func TestLowContextMarker(t *testing.T) {
+	p := agent.Pane{PaneID: "%1", Target: "main:1.0", Session: "main", Path: "/src/app", Provider: "claude",
+		Status: agent.StatusIdle, ContextLow: true}
+	m := testModel(p)
+	i := m.cursor
+	if row := ansi.Strip(m.renderTreeItem(m.items[i], false, 60)); !strings.Contains(row, "◔") {
+		t.Errorf("row %q has no low-context marker", row)
+	}
+	if bar := ansi.Strip(m.renderStatusBar(100)); !strings.Contains(bar, "idle · context low") {
+		t.Errorf("status bar %q doesn't mention low context", bar)
+	}
+
+	m.panes["%1"].ContextLow = false
+	if row := ansi.Strip(m.renderTreeItem(m.items[i], false, 60)); strings.Contains(row, "◔") {
+		t.Errorf("row %q marked without a warning", row)
+	}
+}
+
+func TestFirstPaneByPreference(t *testing.T) {
+	now := time.Now()
+	pane := func(n int, path string, status agent.PaneStatus, active time.Duration) agent.Pane {
+		return agent.Pane{PaneID: fmt.Sprintf("%%%d", n), Target: fmt.Sprintf("main:%d.0", n), Session: "main",
+			Path: path, Order: n, Status: status, LastActive: now.Add(-active)}
+	}
+	mixed := []agent.Pane{
+		pane(1, "/src/api", agent.StatusIdle, time.Minute),
+		pane(2, "/src/api", agent.StatusBusy, 5*time.Minute),
+		pane(3, "/src/web", agent.StatusNeedsAttention, 10*time.Minute),
+		pane(4, "/src/web", agent.StatusIdle, 10*time.Second),
+		pane(5, "/src/old", agent.StatusBusy, 0),
+	}
+	mixed[4].Stashed = true
+	calm := []agent.Pane{
+		pane(1, "/src/api", agent.StatusIdle, time.Minute),
+		pane(2, "/src/api", agent.StatusIdle, 5*time.Minute),
+	}
+	tests := []struct {
+		cursor string
+		panes  []agent.Pane
+		want   string // "" when the preference finds no pane
+	}{
+		{"", mixed, "%3"},
+		{"attention", mixed, "%3"},
+		{"busy", mixed, "%2"}, // stashed %5 is busy too, but stashed
+		{"recent", mixed, "%4"},
+		{"top", mixed, "%1"},
+		{"", calm, ""},
+		{"busy", calm, ""},
+		{"recent", calm, "%1"},
+		{"top", calm, "%1"},
+	}
+	for _, tt := range tests {
+		useConfig(t, config.Config{StartupCursor: tt.cursor})
+		m := testModel(tt.panes...)
+		got := ""
+		if i := m.firstPaneByPreference(); i >= 0 {
+			got = m.items[i].PaneID
+		}
+		if got != tt.want {
+			t.Errorf("startup_cursor %q with %d panes: starts on %q, want %q", tt.cursor, len(tt.panes), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickerItem is one selectable row in a picker.
+type pickerItem struct {
+	label  string
+	detail string
+}
+
+// picker is a small list overlay drawn in place of the preview. onSelect runs
+// with the chosen index.
+type picker struct {
+	title    string
+	items    []pickerItem
+	cursor   int
+	onSelect func(m *Model, idx int) tea.Cmd
+}
+
+// handlePickerKey routes a key press to the active picker. Digits 1-9 select
+// an item directly.
+func (m Model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pk := m.picker
+	key := msg.String()
+	switch key {
+	case "j", "down":
+		pk.cursor = min(pk.cursor+1, len(pk.items)-1)
+	case "k", "up":
+		pk.cursor = max(pk.cursor-1, 0)
+	case "enter":
+		m.picker = nil
+		return m, pk.onSelect(&m, pk.cursor)
+	case "esc", "q", "ctrl+c":
+		m.picker = nil
+	default:
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+			if idx := int(key[0] - '1'); idx < len(pk.items) {
+				m.picker = nil
+				return m, pk.onSelect(&m, idx)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (pk *picker) View(width int) string {
+	var b strings.Builder
+	b.WriteString(helpTitleStyle.Render(" " + pk.title))
+	b.WriteString("\n\n")
+	for i, it := range pk.items {
+		num := " "
+		if i < 9 {
+			num = string(rune('1' + i))
+		}
+		line := "  " + num + "  " + it.label
+		if it.detail != "" {
+			line += "  " + truncate(it.detail, width-dw(line)-3)
+		}
+		if i == pk.cursor {
+			b.WriteString(selectedStyle.Render(line + spaces(width-dw(line))))
+		} else {
+			b.WriteString(helpKeyStyle.UnsetWidth().Render("  "+num) + helpDescStyle.Render(strings.TrimPrefix(line, "  "+num)))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPreviewBytes caps how much captured content reaches the viewport. When
+// exceeded, the oldest lines are dropped since the bottom is what matters.
+const maxPreviewBytes = 256 << 10
+
+// sanitizePreview makes captured pane content safe to render: SGR (color)
+// sequences, newlines and tabs are kept; every other control character and
+// escape sequence (cursor movement, OSC titles, C1 controls) is dropped.
+func sanitizePreview(s string) string {
+	if len(s) > maxPreviewBytes {
+		s = s[len(s)-maxPreviewBytes:]
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == 0x1b:
+			i += copyEscape(&b, s[i:])
+			continue
+		case c == '\n' || c == '\t':
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			// drop C0 controls (including \r)
+		case c < utf8.RuneSelf:
+			b.WriteByte(c)
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r != utf8.RuneError && (r < 0x80 || r > 0x9f) {
+				b.WriteString(s[i : i+size])
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// copyEscape consumes the escape sequence at the start of s, writing it to b
+// only if it is an SGR sequence. It returns the number of bytes consumed.
+func copyEscape(b *strings.Builder, s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[': // CSI: parameters then a final byte in 0x40-0x7e
+		for j := 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7e {
+				if s[j] == 'm' {
+					b.WriteString(s[:j+1])
+				}
+				return j + 1
+			}
+		}
+		return len(s)
+	case ']': // OSC: terminated by BEL or ST (ESC \)
+		for j := 2; j < len(s); j++ {
+			if s[j] == 0x07 {
+				return j + 1
+			}
+			if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2
+			}
+		}
+		return len(s)
+	default:
+		return 2
+	}
+}
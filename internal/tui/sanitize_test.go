@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizePreview(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"keeps SGR", "\x1b[1;32m✓\x1b[0m done", "\x1b[1;32m✓\x1b[0m done"},
+		{"drops cursor movement", "a\x1b[2J\x1b[H\x1b[10;5Hb", "ab"},
+		{"drops OSC title with BEL", "\x1b]0;claude\x07prompt", "prompt"},
+		{"drops OSC hyperlink with ST", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"drops carriage returns and bells", "50%\r100%\x07\n", "50%100%\n"},
+		{"keeps tabs", "a\tb", "a\tb"},
+		{"drops C1 controls", "x\u0085y\u009bz", "xyz"},
+		{"drops a truncated escape", "tail\x1b[38;5", "tail"},
+		{"drops a lone escape", "tail\x1b", "tail"},
+	}
+	for _, tt := range tests {
+		if got := sanitizePreview(tt.in); got != tt.want {
+			t.Errorf("%s: sanitizePreview(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizePreviewCapsSize(t *testing.T) {
+	// A huge table: every line far wider than any terminal.
+	row := strings.Repeat("| cell ", 2000) + "|\n"
+	in := strings.Repeat(row, 100) + "last line\n"
+	got := sanitizePreview(in)
+	if len(got) > maxPreviewBytes {
+		t.Fatalf("len = %d, want at most %d", len(got), maxPreviewBytes)
+	}
+	if !strings.HasSuffix(got, "last line\n") {
+		t.Error("the bottom of the capture was dropped")
+	}
+	if !strings.HasPrefix(got, "| cell") {
+		t.Errorf("capped content starts mid-line: %q", got[:20])
+	}
+}
+
+func TestSanitizePreviewInvalidUTF8(t *testing.T) {
+	in := "build ok\n\x89PNG\r\n\x1a\n\xff\xd8\xff\xe0 \x1b[31mred\x1b[0m \xc3\x28 caf\xc3\xa9\n"
+	got := sanitizePreview(in)
+	if !utf8.ValidString(got) {
+		t.Fatalf("sanitizePreview left invalid UTF-8: %q", got)
+	}
+	if want := "build ok\nPNG\n\n \x1b[31mred\x1b[0m ( café\n"; got != want {
+		t.Errorf("sanitizePreview = %q, want %q", got, want)
+	}
+}
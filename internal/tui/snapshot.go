@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leo/agent-mux/internal/agent"
+)
+
+// Snapshot writes the pane tree, as the interactive list would draw it at the
+// given width, to w and returns: no preview, selection or status bar. Like
+// Summary, colors follow the output's lipgloss color profile.
+func Snapshot(w io.Writer, width int) error {
+	panes, err := agent.ListPanes()
+	if err != nil {
+		return err
+	}
+	m := NewModel("", Options{})
+	m.title = windowTitle{} // a snapshot must not rename the window
+	m.width = width
+	next, _ := m.Update(panesLoadedMsg{panes: panes})
+	m = next.(Model)
+
+	if len(m.items) == 0 {
+		fmt.Fprintln(w, "no agent panes")
+		return nil
+	}
+	for _, item := range m.items {
+		fmt.Fprintln(w, strings.TrimRight(m.renderTreeItem(item, false, width), " "))
+	}
+	return nil
+}
@@ -1,22 +1,78 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"hash/fnv"
+	"os"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leo/agent-mux/internal/agent"
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// iconSet holds the styles of the status icons (see statusGlyphs) and row
+// text in one context: normal, selected or stashed.
 type iconSet struct {
-	busy      string
-	attention string
-	idle      string
+	busy      lipgloss.Style
+	attention lipgloss.Style
+	auth      lipgloss.Style
+	done      lipgloss.Style
+	idle      lipgloss.Style
 	text      lipgloss.Style
 	dim       lipgloss.Style
 }
 
+// statusGlyphs are the default status icons, drawn in the iconSet styles.
+var statusGlyphs = struct{ busy, attention, auth, done, idle string }{"●", "●", "◆", "✓", "○"}
+
+// statusIcon renders the icon of a pane with the given status in icons'
+// styles. The provider's own icon from config, if any, replaces the glyph
+// (except the transient completion check) while the color still tells the
+// status.
+func statusIcon(icons iconSet, providerName string, status agent.PaneStatus, done bool) string {
+	var style lipgloss.Style
+	var glyph string
+	switch {
+	case done:
+		return icons.done.Render(statusGlyphs.done)
+	case status == agent.StatusBusy:
+		style, glyph = icons.busy, statusGlyphs.busy
+	case status == agent.StatusNeedsAttention, status == agent.StatusUnread:
+		style, glyph = icons.attention, statusGlyphs.attention
+	case status == agent.StatusNeedsAuth:
+		style, glyph = icons.auth, statusGlyphs.auth
+	default:
+		style, glyph = icons.idle, statusGlyphs.idle
+	}
+	if r := []rune(config.Get().Providers[providerName].Icon); len(r) > 0 {
+		glyph = string(r[0])
+	}
+	return style.Render(glyph)
+}
+
+// providerStyle returns the style of a provider's name, in the provider's
+// color from config or its built-in one, or fallback when it has neither.
 func providerStyle(provider string, fallback lipgloss.Style) lipgloss.Style {
+	if c := config.Get().Providers[provider].Color; c != "" {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+	}
 	if c, ok := providerColors[provider]; ok {
 		return lipgloss.NewStyle().Foreground(c)
 	}
 	return fallback
 }
 
+// workspaceNameStyle returns the style of a workspace header's name. With
+// colorize_workspaces set, each path hashes to a stable accent color from
+// workspacePalette so a project looks the same in every session.
+func workspaceNameStyle(path string) lipgloss.Style {
+	if !config.Get().ColorizeWorkspaces || os.Getenv("NO_COLOR") != "" {
+		return workspaceStyle
+	}
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return workspaceStyle.Foreground(workspacePalette[h.Sum32()%uint32(len(workspacePalette))])
+}
+
 var (
 	// Tree items
 	selectedStyle = lipgloss.NewStyle().
@@ -37,6 +93,11 @@ var (
 	dimStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8"))
 
+	jumpLabelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("11")).
+			Bold(true)
+
 	// Separator
 	separatorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8"))
@@ -45,6 +106,18 @@ var (
 	stashedSectionStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("242"))
 
+	// Status bar
+	statusBarStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("8"))
+
+	confirmStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Bold(true)
+	inputStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("15"))
+	inputCursorStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("15"))
+
 	// Help
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8"))
@@ -54,7 +127,7 @@ var (
 	helpKeyStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("15")).
 			Bold(true).
-			Width(8)
+			Width(10)
 	helpDescStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8"))
 
@@ -69,29 +142,53 @@ var (
 		"smelt":    lipgloss.Color("#EAB308"),
 	}
 
+	// Workspace accents for colorize_workspaces; all readable on a dark
+	// background and distinct from the status icon colors.
+	workspacePalette = []lipgloss.Color{
+		lipgloss.Color("#60A5FA"),
+		lipgloss.Color("#F472B6"),
+		lipgloss.Color("#FBBF24"),
+		lipgloss.Color("#4ADE80"),
+		lipgloss.Color("#C084FC"),
+		lipgloss.Color("#2DD4BF"),
+		lipgloss.Color("#FB923C"),
+		lipgloss.Color("#A3E635"),
+	}
+
+	// Preview line that made the pane need attention
+	attentionLineStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("15")).
+				Background(lipgloss.Color("#2E2E5C"))
+
 	// Error
 	errStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("1"))
 
 	// Icon sets for status × context
 	normalIcons = iconSet{
-		busy:      lipgloss.NewStyle().Foreground(lipgloss.Color("#D97706")).Render("●"),
-		attention: lipgloss.NewStyle().Foreground(lipgloss.Color("#9B9BF5")).Render("●"),
-		idle:      lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("○"),
+		busy:      lipgloss.NewStyle().Foreground(lipgloss.Color("#D97706")),
+		attention: lipgloss.NewStyle().Foreground(lipgloss.Color("#9B9BF5")),
+		auth:      lipgloss.NewStyle().Foreground(lipgloss.Color("#F87171")),
+		done:      lipgloss.NewStyle().Foreground(lipgloss.Color("#34D399")),
+		idle:      lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
 		text:      paneItemStyle,
 		dim:       dimStyle,
 	}
 	selectedIcons = iconSet{
-		busy:      lipgloss.NewStyle().Foreground(lipgloss.Color("#D97706")).Background(lipgloss.Color("8")).Render("●"),
-		attention: lipgloss.NewStyle().Foreground(lipgloss.Color("#9B9BF5")).Background(lipgloss.Color("8")).Render("●"),
-		idle:      lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Background(lipgloss.Color("8")).Render("○"),
+		busy:      lipgloss.NewStyle().Foreground(lipgloss.Color("#D97706")).Background(lipgloss.Color("8")),
+		attention: lipgloss.NewStyle().Foreground(lipgloss.Color("#9B9BF5")).Background(lipgloss.Color("8")),
+		auth:      lipgloss.NewStyle().Foreground(lipgloss.Color("#F87171")).Background(lipgloss.Color("8")),
+		done:      lipgloss.NewStyle().Foreground(lipgloss.Color("#34D399")).Background(lipgloss.Color("8")),
+		idle:      lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Background(lipgloss.Color("8")),
 		text:      selectedStyle,
 		dim:       selectedStyle,
 	}
 	stashedIcons = iconSet{
-		busy:      lipgloss.NewStyle().Foreground(lipgloss.Color("242")).Render("●"),
-		attention: lipgloss.NewStyle().Foreground(lipgloss.Color("242")).Render("●"),
-		idle:      lipgloss.NewStyle().Foreground(lipgloss.Color("242")).Render("○"),
+		busy:      lipgloss.NewStyle().Foreground(lipgloss.Color("242")),
+		attention: lipgloss.NewStyle().Foreground(lipgloss.Color("242")),
+		auth:      lipgloss.NewStyle().Foreground(lipgloss.Color("242")),
+		done:      lipgloss.NewStyle().Foreground(lipgloss.Color("242")),
+		idle:      lipgloss.NewStyle().Foreground(lipgloss.Color("242")),
 		text:      lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
 		dim:       lipgloss.NewStyle().Foreground(lipgloss.Color("242")),
 	}
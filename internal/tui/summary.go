@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/leo/agent-mux/internal/agent"
+	"github.com/leo/agent-mux/internal/config"
+)
+
+// Summary writes a one-shot table of agent panes to w, grouped by workspace:
+// provider, session:window, status and idle time. Columns are sized to their
+// contents and squeezed to fit width (0 means no limit). Colors follow the
+// lipgloss color profile of the output, so they drop out when piped or when
+// NO_COLOR is set.
+func Summary(w io.Writer, width int) error {
+	panes, err := agent.ListPanes()
+	if err != nil {
+		return err
+	}
+	r := agent.NewReconciler()
+	if state, ok := agent.LoadState(); ok {
+		r.SeedFromState(state)
+	}
+	r.Reconcile(panes)
+
+	if len(panes) == 0 {
+		fmt.Fprintln(w, "no agent panes")
+		return nil
+	}
+
+	// Group by workspace in tmux order: worktrees under their project root,
+	// other panes by path, or by session with group_by "session".
+	var keys []string
+	groups := make(map[string][]*agent.Pane)
+	labels := make(map[string]string)
+	for i := range panes {
+		p := &panes[i]
+		key, label := p.Path, p.ShortPath
+		switch {
+		case groupBySession():
+			key, label = "session:"+p.Session, p.Session
+		case p.ProjectRoot != "" && p.ProjectRoot != p.Path:
+			key, label = p.ProjectRoot, p.ProjectShort
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			labels[key] = label
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	cfg := config.Get()
+	type row struct{ provider, target, status, elapsed string }
+	rows := make(map[*agent.Pane]row, len(panes))
+	var provW, targetW, statusW int
+	for i := range panes {
+		p := &panes[i]
+		rw := row{
+			provider: p.Provider,
+			target:   p.Session + ":" + p.Window,
+			status:   p.Status.String(),
+		}
+		if !p.LastActive.IsZero() && p.Status != agent.StatusBusy {
+			rw.elapsed = formatElapsed(time.Since(p.LastActive), cfg.ElapsedFormat) + cfg.ElapsedSuffix
+		}
+		rows[p] = rw
+		provW = max(provW, dw(rw.provider))
+		targetW = max(targetW, dw(rw.target))
+		statusW = max(statusW, dw(rw.status))
+	}
+
+	// Each row is "  ● provider  target  status  elapsed". On a narrow
+	// terminal the target column shrinks first, then the provider column
+	// is dropped.
+	elapsedW := elapsedWidth(cfg.ElapsedFormat) + dw(cfg.ElapsedSuffix)
+	lineW := func() int {
+		n := 4 + targetW + 2 + statusW + 2 + elapsedW
+		if provW > 0 {
+			n += provW + 2
+		}
+		return n
+	}
+	if width > 0 && lineW() > width {
+		targetW = max(targetW-(lineW()-width), 8)
+	}
+	if width > 0 && lineW() > width {
+		provW = 0
+		targetW = max(targetW-(lineW()-width), 8)
+	}
+
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		label := labels[key]
+		if width > 0 {
+			label = truncatePath(label, width)
+		}
+		fmt.Fprintln(w, workspaceNameStyle(key).Render(label))
+		for _, p := range groups[key] {
+			rw := rows[p]
+			var b strings.Builder
+			b.WriteString("  " + statusIcon(normalIcons, p.Provider, p.Status, false) + " ")
+			if provW > 0 {
+				b.WriteString(providerStyle(p.Provider, paneItemStyle).Render(pad(rw.provider, provW)) + "  ")
+			}
+			b.WriteString(pad(truncate(rw.target, targetW), targetW) + "  ")
+			status := rw.status
+			if rw.elapsed != "" {
+				status = pad(status, statusW)
+			}
+			if p.Status.WantsAttention() {
+				status = confirmStyle.Render(status)
+			}
+			b.WriteString(status)
+			if rw.elapsed != "" {
+				b.WriteString("  " + dimStyle.Render(rw.elapsed))
+			}
+			fmt.Fprintln(w, b.String())
+		}
+	}
+	return nil
+}
+
+// pad right-pads s with spaces to display width n.
+func pad(s string, n int) string {
+	return s + spaces(n-dw(s))
+}
@@ -2,11 +2,14 @@ package tui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/leo/agent-mux/internal/agent"
+	"github.com/leo/agent-mux/internal/config"
 )
 
 // dw returns the display width of s, accounting for ANSI escapes and wide
@@ -22,25 +25,36 @@ const (
 	KindPane
 	KindSectionHeader
 	KindProjectGroup
+	KindOther  // a non-agent pane, listed for debugging detection
+	KindRecent // a directory an agent used to run in (show_recent_projects); Group names it
 )
 
 // TreeItem is one visible row in the flattened tree.
 type TreeItem struct {
 	Kind        ItemKind
 	PaneID      string // stable tmux pane id (KindPane) or first pane id in workspace (KindWorkspace)
-	HeaderTitle string // for KindSectionHeader
+	HeaderTitle string // for KindSectionHeader, and KindWorkspace when grouped by session
+	Group       string // collapse key of the workspace/project the row belongs to
+	Collapsed   bool   // header of a collapsed group
 }
 
-// NextPane returns the index of the next KindPane item after from, wrapping around if none.
+// selectable reports whether the cursor can rest on the item: panes, recent
+// projects, and the headers of collapsed groups (so they can be expanded
+// again).
+func (it TreeItem) selectable() bool {
+	return it.Kind == KindPane || it.Kind == KindRecent || it.Collapsed
+}
+
+// NextPane returns the index of the next selectable item after from, wrapping around if none.
 func NextPane(items []TreeItem, from int) int {
 	for i := from + 1; i < len(items); i++ {
-		if items[i].Kind == KindPane {
+		if items[i].selectable() {
 			return i
 		}
 	}
 	if len(items) > 0 {
 		for i := range from {
-			if items[i].Kind == KindPane {
+			if items[i].selectable() {
 				return i
 			}
 		}
@@ -48,16 +62,16 @@ func NextPane(items []TreeItem, from int) int {
 	return from
 }
 
-// PrevPane returns the index of the previous KindPane item before from, wrapping around if none.
+// PrevPane returns the index of the previous selectable item before from, wrapping around if none.
 func PrevPane(items []TreeItem, from int) int {
 	for i := from - 1; i >= 0; i-- {
-		if items[i].Kind == KindPane {
+		if items[i].selectable() {
 			return i
 		}
 	}
 	if len(items) > 0 {
 		for i := len(items) - 1; i > from; i-- {
-			if items[i].Kind == KindPane {
+			if items[i].selectable() {
 				return i
 			}
 		}
@@ -65,7 +79,7 @@ func PrevPane(items []TreeItem, from int) int {
 	return from
 }
 
-// NearestPane returns the closest KindPane to the given index without wrapping.
+// NearestPane returns the closest selectable item to the given index without wrapping.
 func NearestPane(items []TreeItem, from int) int {
 	if len(items) == 0 {
 		return 0
@@ -76,7 +90,7 @@ func NearestPane(items []TreeItem, from int) int {
 	if from < 0 {
 		from = 0
 	}
-	if items[from].Kind == KindPane {
+	if items[from].selectable() {
 		return from
 	}
 
@@ -85,7 +99,7 @@ func NearestPane(items []TreeItem, from int) int {
 	// Find closest pane within the current section (by distance).
 	best := -1
 	for i := sectionStart; i < sectionEnd; i++ {
-		if items[i].Kind != KindPane {
+		if !items[i].selectable() {
 			continue
 		}
 		dist := i - from
@@ -106,18 +120,94 @@ func NearestPane(items []TreeItem, from int) int {
 
 	// Fall back to other sections.
 	for i := from - 1; i >= 0; i-- {
-		if items[i].Kind == KindPane {
+		if items[i].selectable() {
 			return i
 		}
 	}
 	for i := from + 1; i < len(items); i++ {
-		if items[i].Kind == KindPane {
+		if items[i].selectable() {
 			return i
 		}
 	}
 	return 0
 }
 
+// indexOfItem returns the index of the selectable item in items that is the
+// same row as it, or -1. Panes match by pane id; collapsed headers by group,
+// since a header's PaneID is just whichever pane happens to be listed first.
+func indexOfItem(items []TreeItem, it TreeItem) int {
+	if !it.selectable() {
+		return -1
+	}
+	for i, cand := range items {
+		if !cand.selectable() || cand.Kind != it.Kind {
+			continue
+		}
+		if it.Kind == KindPane && cand.PaneID == it.PaneID || it.Kind != KindPane && cand.Group == it.Group {
+			return i
+		}
+	}
+	return -1
+}
+
+// workspaceEntries returns, for each workspace/project header in items, the
+// header's index and the index the cursor lands on when jumping to it: the
+// header itself when collapsed, otherwise its first pane.
+func workspaceEntries(items []TreeItem) (headers, entries []int) {
+	for i, it := range items {
+		if it.Kind != KindWorkspace && it.Kind != KindProjectGroup {
+			continue
+		}
+		switch {
+		case it.Collapsed:
+			headers, entries = append(headers, i), append(entries, i)
+		case i+1 < len(items) && items[i+1].Kind == KindPane:
+			headers, entries = append(headers, i), append(entries, i+1)
+		}
+	}
+	return headers, entries
+}
+
+// currentWorkspace returns the position in headers of the workspace that
+// contains from, or -1 if from precedes every header.
+func currentWorkspace(headers []int, from int) int {
+	cur := -1
+	for k, h := range headers {
+		if h <= from {
+			cur = k
+		}
+	}
+	return cur
+}
+
+// NextWorkspacePane returns the index of the first pane of the workspace after
+// the one containing from, wrapping around.
+func NextWorkspacePane(items []TreeItem, from int) int {
+	headers, entries := workspaceEntries(items)
+	if len(entries) == 0 {
+		return from
+	}
+	next := currentWorkspace(headers, from) + 1
+	if next >= len(entries) {
+		next = 0
+	}
+	return entries[next]
+}
+
+// PrevWorkspacePane returns the index of the first pane of the workspace
+// before the one containing from, wrapping around.
+func PrevWorkspacePane(items []TreeItem, from int) int {
+	headers, entries := workspaceEntries(items)
+	if len(entries) == 0 {
+		return from
+	}
+	prev := currentWorkspace(headers, from) - 1
+	if prev < 0 {
+		prev = len(entries) - 1
+	}
+	return entries[prev]
+}
+
 // sectionBounds returns the start (inclusive) and end (exclusive) indices of
 // the section containing the given index.
 func sectionBounds(items []TreeItem, idx int) (int, int) {
@@ -138,20 +228,20 @@ func sectionBounds(items []TreeItem, idx int) (int, int) {
 	return start, end
 }
 
-// LastPane returns the index of the last KindPane item, or 0 if none.
+// LastPane returns the index of the last selectable item, or 0 if none.
 func LastPane(items []TreeItem) int {
 	for i := len(items) - 1; i >= 0; i-- {
-		if items[i].Kind == KindPane {
+		if items[i].selectable() {
 			return i
 		}
 	}
 	return 0
 }
 
-// FirstPane returns the index of the first KindPane item, or -1 if none.
+// FirstPane returns the index of the first selectable item, or -1 if none.
 func FirstPane(items []TreeItem) int {
 	for i, it := range items {
-		if it.Kind == KindPane {
+		if it.selectable() {
 			return i
 		}
 	}
@@ -169,6 +259,13 @@ func (m Model) renderTreeItem(item TreeItem, selected bool, width int) string {
 		return stashedSectionStyle.Render("─" + label + strings.Repeat("─", lineLen))
 	}
 
+	if item.Kind == KindOther {
+		return m.renderOtherRow(item.PaneID, width)
+	}
+	if item.Kind == KindRecent {
+		return m.renderRecentRow(item, selected, width)
+	}
+
 	p := m.panes[item.PaneID]
 	if p == nil {
 		return ""
@@ -176,16 +273,35 @@ func (m Model) renderTreeItem(item TreeItem, selected bool, width int) string {
 
 	switch item.Kind {
 	case KindWorkspace:
-		return renderWorkspaceHeader(p, width)
+		if item.HeaderTitle != "" {
+			return renderHeaderLine(truncate(item.HeaderTitle, width-2-dw(m.headerCount(item))-1), m.headerCount(item), "",
+				workspaceNameStyle(item.HeaderTitle), item.Collapsed, selected, width)
+		}
+		return renderWorkspaceHeader(p, m.headerCount(item), workspaceNameStyle(p.Path), item.Collapsed, selected, width)
 	case KindProjectGroup:
-		return renderProjectGroupHeader(p, width)
+		return renderProjectGroupHeader(p, m.headerCount(item), workspaceNameStyle(p.ProjectRoot), item.Collapsed, selected, width)
 	case KindPane:
 		return m.renderPaneRow(p, selected, width)
 	}
 	return ""
 }
 
-func renderProjectGroupHeader(p *agent.Pane, width int) string {
+// headerCount summarizes a header's group, e.g. "(3)", for workspaces with
+// several panes. Collapsed headers always show it, with the number of panes
+// wanting attention appended since their icons are hidden: "(3 · 1!)".
+func (m Model) headerCount(item TreeItem) string {
+	g := m.groupSizes[item.Group]
+	if g.panes < 2 && !item.Collapsed {
+		return ""
+	}
+	s := strconv.Itoa(g.panes)
+	if item.Collapsed && g.attention > 0 {
+		s += fmt.Sprintf(" · %d!", g.attention)
+	}
+	return "(" + s + ")"
+}
+
+func renderProjectGroupHeader(p *agent.Pane, count string, style lipgloss.Style, collapsed, selected bool, width int) string {
 	name := p.ProjectShort
 	if name == "" {
 		name = p.ShortPath
@@ -196,6 +312,9 @@ func renderProjectGroupHeader(p *agent.Pane, width int) string {
 	}
 
 	avail := width - 2
+	if count != "" {
+		avail -= dw(count) + 1
+	}
 	if branch != "" {
 		needed := dw(name) + 1 + dw(branch)
 		if needed > avail {
@@ -207,24 +326,23 @@ func renderProjectGroupHeader(p *agent.Pane, width int) string {
 			}
 		}
 		if branch == "" {
-			name = truncate(name, avail)
+			name = truncatePath(name, avail)
 		}
 	} else {
-		name = truncate(name, avail)
+		name = truncatePath(name, avail)
 	}
 
-	text := " " + name
-	if branch != "" {
-		pad := max(width-dw(text)-dw(branch)-1, 0)
-		text += strings.Repeat(" ", pad)
-		return workspaceStyle.Render(text) + branchStyle.Render(branch) + branchStyle.Render(" ")
-	}
-	text += strings.Repeat(" ", max(width-dw(text), 0))
-	return workspaceStyle.Render(text)
+	return renderHeaderLine(name, count, branch, style, collapsed, selected, width)
 }
 
-func renderWorkspaceHeader(p *agent.Pane, width int) string {
+func renderWorkspaceHeader(p *agent.Pane, count string, style lipgloss.Style, collapsed, selected bool, width int) string {
+	if p.PathMissing {
+		count = strings.TrimSpace("(missing) " + count)
+	}
 	avail := width - 2
+	if count != "" {
+		avail -= dw(count) + 1
+	}
 	name := p.ShortPath
 	branch := p.GitBranch
 	if branch != "" && p.GitDirty {
@@ -242,20 +360,38 @@ func renderWorkspaceHeader(p *agent.Pane, width int) string {
 			}
 		}
 		if branch == "" {
-			name = truncate(name, avail)
+			name = truncatePath(name, avail)
 		}
 	} else {
-		name = truncate(name, avail)
+		name = truncatePath(name, avail)
 	}
 
-	text := " " + name
+	return renderHeaderLine(name, count, branch, style, collapsed, selected, width)
+}
+
+// renderHeaderLine lays out a workspace/project header: name (in nameStyle)
+// and dimmed pane count on the left, branch right-aligned. Collapsed headers
+// are marked with ▸.
+func renderHeaderLine(name, count, branch string, nameStyle lipgloss.Style, collapsed, selected bool, width int) string {
+	lead := " "
+	if collapsed {
+		lead = "▸"
+	}
+	cStyle, bStyle := dimStyle, branchStyle
+	if selected {
+		nameStyle, cStyle, bStyle = selectedStyle, selectedStyle, selectedStyle
+	}
+	text := lead + name
+	line := nameStyle.Render(text)
+	if count != "" {
+		line += cStyle.Render(" " + count)
+		text += " " + count
+	}
 	if branch != "" {
 		pad := max(width-dw(text)-dw(branch)-1, 0)
-		text += strings.Repeat(" ", pad)
-		return workspaceStyle.Render(text) + branchStyle.Render(branch) + branchStyle.Render(" ")
+		return line + nameStyle.Render(strings.Repeat(" ", pad)) + bStyle.Render(branch) + bStyle.Render(" ")
 	}
-	text += strings.Repeat(" ", max(width-dw(text), 0))
-	return workspaceStyle.Render(text)
+	return line + nameStyle.Render(strings.Repeat(" ", max(width-dw(text), 0)))
 }
 
 func (m Model) renderPaneRow(p *agent.Pane, selected bool, width int) string {
@@ -267,26 +403,43 @@ func (m Model) renderPaneRow(p *agent.Pane, selected bool, width int) string {
 	}
 
 	// Worktree label: dim, only for actual worktrees (Path != ProjectRoot).
+	// In the flat list there is no header to name the path, so every row
+	// carries it.
 	worktree := ""
-	if p.ShortPath != "" && p.Path != p.ProjectRoot {
+	if p.ShortPath != "" && (p.Path != p.ProjectRoot || m.flat || groupBySession()) {
 		worktree = p.ShortPath
 	}
 
 	// Timer column has a fixed width so the right edge stays aligned across
-	// busy rows (no timer) and idle rows. formatElapsed uses a single unit,
-	// max " 999s "-ish; 5 cols covers the common case.
-	const elapsedSlotW = 5
+	// busy rows (no timer) and idle rows. The default format uses a single
+	// unit, max " 99m "-ish; 5 cols covers the common case.
+	cfg := config.Get()
+	elapsedSlotW := 2 + elapsedWidth(cfg.ElapsedFormat) + dw(cfg.ElapsedSuffix)
 	elapsedRendered := strings.Repeat(" ", elapsedSlotW)
-	if !p.LastActive.IsZero() && p.Status != agent.StatusBusy {
-		v := " " + formatElapsed(time.Since(p.LastActive)) + " "
+	if p.InMode {
+		elapsedRendered = spaces(elapsedSlotW-5) + " copy"
+	} else if !p.LastActive.IsZero() && p.Status != agent.StatusBusy {
+		v := " " + formatElapsed(time.Since(p.LastActive), cfg.ElapsedFormat) + cfg.ElapsedSuffix + " "
 		if dw(v) > elapsedSlotW {
 			v = truncate(v, elapsedSlotW)
 		}
 		elapsedRendered = strings.Repeat(" ", elapsedSlotW-dw(v)) + v
 	}
 
+	// A low-context warning is advisory, so it gets a dim marker after the
+	// window label rather than a status icon.
+	marks := ""
+	if p.ContextLow {
+		marks = " ◔"
+	}
+	// Same for another agent of the same provider in the same directory
+	// (mark_duplicates); the status bar says how many.
+	if m.duplicates[p.PaneID] > 0 {
+		marks += " ⧉"
+	}
+
 	prefix := "   "
-	middleAvail := width - dw(prefix) - 2 - elapsedSlotW // 2 = icon cell + leading space
+	middleAvail := width - dw(prefix) - 2 - elapsedSlotW - dw(marks) // 2 = icon cell + leading space
 
 	// window:idx is always shown in full; truncate as a last resort if
 	// somehow wider than the available middle.
@@ -299,7 +452,7 @@ func (m Model) renderPaneRow(p *agent.Pane, selected bool, width int) string {
 	// When panes share a project root, align their worktree labels by
 	// padding the separator so every window label consumes the same width.
 	sepW := 2
-	if targetW, ok := m.projectWinWidth[p.ProjectRoot]; ok && targetW > dw(winLabel) {
+	if targetW, ok := m.projectWinWidth[m.workspaceKey(p)]; ok && targetW > dw(winLabel) {
 		aligned := 2 + targetW - dw(winLabel)
 		if remaining >= aligned+2 {
 			sepW = aligned
@@ -310,11 +463,18 @@ func (m Model) renderPaneRow(p *agent.Pane, selected bool, width int) string {
 	if worktree != "" && remaining >= sepW+2 {
 		avail := remaining - sepW
 		if dw(worktree) > avail {
-			worktree = truncate(worktree, avail)
+			worktree = truncatePath(worktree, avail)
 		}
 		worktreeRendered = strings.Repeat(" ", sepW) + worktree
 	}
-	gap := max(remaining-dw(worktreeRendered), 0)
+	// Notes follow the worktree label, dimmed, in whatever space is left.
+	noteRendered := ""
+	if note := m.notes[agent.NoteKey(p)]; note != "" {
+		if avail := remaining - dw(worktreeRendered) - 2; avail >= 4 {
+			noteRendered = "  " + truncate(note, avail)
+		}
+	}
+	gap := max(remaining-dw(worktreeRendered)-dw(noteRendered), 0)
 
 	icons := normalIcons
 	if selected {
@@ -323,18 +483,10 @@ func (m Model) renderPaneRow(p *agent.Pane, selected bool, width int) string {
 		icons = stashedIcons
 	}
 
-	var icon string
-	switch p.Status {
-	case agent.StatusBusy:
-		icon = icons.busy
-	case agent.StatusNeedsAttention, agent.StatusUnread:
-		icon = icons.attention
-	default:
-		icon = icons.idle
-	}
+	icon := statusIcon(icons, p.Provider, p.Status, m.justCompleted(p))
 
 	if selected {
-		body := " " + winLabel + worktreeRendered + strings.Repeat(" ", gap) + elapsedRendered
+		body := " " + winLabel + marks + worktreeRendered + noteRendered + strings.Repeat(" ", gap) + elapsedRendered
 		return selectedStyle.Render(prefix) + icon + selectedStyle.Render(body)
 	}
 
@@ -343,29 +495,104 @@ func (m Model) renderPaneRow(p *agent.Pane, selected bool, width int) string {
 		winStyle = providerStyle(p.Provider, icons.text)
 	}
 	line := icons.text.Render(prefix) + icon + icons.text.Render(" ") + winStyle.Render(winLabel)
+	if marks != "" {
+		line += icons.dim.Render(marks)
+	}
 	if worktreeRendered != "" {
 		line += icons.dim.Render(worktreeRendered)
 	}
+	if noteRendered != "" {
+		line += icons.dim.Italic(true).Render(noteRendered)
+	}
 	line += icons.dim.Render(strings.Repeat(" ", gap) + elapsedRendered)
 	return line
 }
 
-// truncate shortens s to maxLen, adding ellipsis if needed.
+// renderOtherRow renders a non-agent pane: dimmed, with the command tmux
+// reports for it in place of a provider.
+func (m Model) renderOtherRow(paneID string, width int) string {
+	p := m.others[paneID]
+	if p == nil {
+		return ""
+	}
+	text := "   · " + p.Session + ":" + p.Window + "  " + p.Command
+	text = truncate(text, width)
+	return stashedSectionStyle.Render(text + spaces(width-dw(text)))
+}
+
+// renderRecentRow renders a recent project: the provider that ran there, its
+// path and how long ago, dimmed like the stashed section.
+func (m Model) renderRecentRow(item TreeItem, selected bool, width int) string {
+	r := m.recentAt(item)
+	if r == nil {
+		return ""
+	}
+	path := r.ShortPath
+	if path == "" {
+		path = r.Path
+	}
+	elapsed := ""
+	if !r.LastActive.IsZero() {
+		cfg := config.Get()
+		elapsed = " " + formatElapsed(time.Since(r.LastActive), cfg.ElapsedFormat) + cfg.ElapsedSuffix + " "
+	}
+	head := "   ↺ " + r.Provider + "  "
+	text := head + truncatePath(path, width-dw(head)-dw(elapsed))
+	text += spaces(width-dw(text)-dw(elapsed)) + elapsed
+	if selected {
+		return selectedStyle.Render(text)
+	}
+	return stashedSectionStyle.Render(text)
+}
+
+func spaces(n int) string {
+	return strings.Repeat(" ", max(n, 0))
+}
+
+// truncate shortens s to maxLen display columns, ending it with an ellipsis
+// if needed.
 func truncate(s string, maxLen int) string {
 	if maxLen <= 0 {
 		return ""
 	}
-	if len(s) <= maxLen {
+	return ansi.Truncate(s, maxLen, "…")
+}
+
+// truncateLeft shortens s to maxLen display columns by cutting from the
+// start, keeping the end: "…/bar/baz".
+func truncateLeft(s string, maxLen int) string {
+	if maxLen <= 0 {
+		return ""
+	}
+	w := dw(s)
+	if w <= maxLen {
 		return s
 	}
-	if maxLen <= 3 {
-		return s[:maxLen]
+	// A wide character straddling the cut is kept whole; cut further until
+	// the result fits. Cutting everything leaves just the ellipsis.
+	for n := w - maxLen + 1; n < w; n++ {
+		if t := ansi.TruncateLeft(s, n, "…"); dw(t) <= maxLen {
+			return t
+		}
 	}
-	return s[:maxLen-3] + "…"
+	return truncate("…", maxLen)
 }
 
-// formatElapsed returns a compact duration string using a single unit.
-func formatElapsed(d time.Duration) string {
+// truncatePath shortens a path with the truncate_paths strategy: from the
+// end by default, or from the start to keep its most specific part.
+func truncatePath(s string, maxLen int) string {
+	if config.Get().TruncatePaths == "start" {
+		return truncateLeft(s, maxLen)
+	}
+	return truncate(s, maxLen)
+}
+
+// formatElapsed returns a compact duration string. The default style uses a
+// single unit ("45s", "3m", "2h", "4d"); "seconds" always counts seconds.
+func formatElapsed(d time.Duration, style string) string {
+	if style == "seconds" {
+		return fmt.Sprintf("%ds", min(int(d.Seconds()), 99999))
+	}
 	switch {
 	case d < time.Minute:
 		return fmt.Sprintf("%ds", int(d.Seconds()))
@@ -378,6 +605,15 @@ func formatElapsed(d time.Duration) string {
 	}
 }
 
+// elapsedWidth is the widest value formatElapsed usually produces for style,
+// used to size the timer column.
+func elapsedWidth(style string) int {
+	if style == "seconds" {
+		return 6 // "99999s"
+	}
+	return 3 // "59m"
+}
+
 // VisibleSlice returns the start index for scrolling the tree view.
 func VisibleSlice(total, cursor, height int) int {
 	if total <= height {
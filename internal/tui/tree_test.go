@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/leo/agent-mux/internal/config"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s           string
+		max         int
+		right, left string
+	}{
+		{"~/src/api", 20, "~/src/api", "~/src/api"},
+		{"~/src/api", 9, "~/src/api", "~/src/api"},
+		{"~/src/agent-mux/internal/tui", 12, "~/src/agent…", "…nternal/tui"},
+		{"~/src/agent-mux/internal/tui", 1, "…", "…"},
+		{"~/src/agent-mux/internal/tui", 0, "", ""},
+		{"~/src/日本語/プロジェクト", 10, "~/src/日…", "…ジェクト"}, // a wide character never straddles the cut
+		{"~/src/日本語/プロジェクト", 11, "~/src/日本…", "…ロジェクト"},
+		{"feature/ünïcödé-branch", 10, "feature/ü…", "…dé-branch"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.max); got != tt.right {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.right)
+		}
+		if got := truncateLeft(tt.s, tt.max); got != tt.left {
+			t.Errorf("truncateLeft(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.left)
+		}
+		for _, got := range []string{truncate(tt.s, tt.max), truncateLeft(tt.s, tt.max)} {
+			if dw(got) > max(tt.max, 0) {
+				t.Errorf("%q is %d columns, wider than %d", got, dw(got), tt.max)
+			}
+		}
+	}
+}
+
+func TestTruncatePath(t *testing.T) {
+	path := "~/src/agent-mux/internal/tui"
+	tests := []struct{ strategy, want string }{
+		{"", "~/src/agent…"},
+		{"end", "~/src/agent…"},
+		{"start", "…nternal/tui"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			useConfig(t, config.Config{TruncatePaths: tt.strategy})
+			if got := truncatePath(path, 12); got != tt.want {
+				t.Errorf("truncatePath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
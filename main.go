@@ -3,34 +3,94 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"slices"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/leo/agent-mux/internal/agent"
-	_ "github.com/leo/agent-mux/internal/provider" // register all providers
+	"github.com/leo/agent-mux/internal/config"
+	"github.com/leo/agent-mux/internal/provider"
 	"github.com/leo/agent-mux/internal/tui"
 )
 
 func main() {
-	if os.Getenv("TMUX") == "" {
-		fmt.Fprintln(os.Stderr, "error: agent-mux must be run inside tmux")
+	if socket, ok := flagValue("--socket"); ok {
+		agent.SetSocket(socket)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	for _, cp := range cfg.CustomProviders {
+		provider.RegisterCustom(provider.Spec{
+			Name:           cp.Name,
+			BusyIndicators: cp.Busy,
+			ArgsToken:      cp.ArgsToken,
+			BusyScanLines:  cp.BusyScanLines,
+			BusyWindow:     cp.BusyWindow,
+			NoQuestions:    cp.NoQuestionHeuristic,
+			QuitKeys:       cp.QuitKeys,
+		})
+	}
+
+	// Detection testing works on a saved frame and needs no tmux.
+	if slices.Contains(os.Args[1:], "--test-detect") {
+		runTestDetect()
+		return
+	}
+
+	if os.Getenv("TMUX") == "" && !agent.TmuxReachable() {
+		fmt.Fprintln(os.Stderr, "error: agent-mux must be run inside tmux (or with --socket pointing at a running server)")
 		os.Exit(1)
 	}
 
 	if slices.Contains(os.Args[1:], "watch") {
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 		defer stop()
-		if err := agent.Watch(ctx); err != nil {
+		watch := agent.Watch
+		if slices.Contains(os.Args[1:], "--json") {
+			watch = func(ctx context.Context) error { return agent.WatchEvents(ctx, os.Stdout) }
+		}
+		if err := watch(ctx); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		return
 	}
 
+	if slices.Contains(os.Args[1:], "--summary") {
+		width := 0
+		if term.IsTerminal(os.Stdout.Fd()) {
+			width, _, _ = term.GetSize(os.Stdout.Fd())
+		}
+		if err := tui.Summary(os.Stdout, width); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if slices.Contains(os.Args[1:], "--snapshot") {
+		width := 80
+		if term.IsTerminal(os.Stdout.Fd()) {
+			width, _, _ = term.GetSize(os.Stdout.Fd())
+		}
+		if err := tui.Snapshot(os.Stdout, width); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if slices.Contains(os.Args[1:], "--bench") || slices.Contains(os.Args[1:], "--bench-cold") {
 		runBench(slices.Contains(os.Args[1:], "--bench-cold"))
 		return
@@ -40,19 +100,127 @@ func main() {
 		return
 	}
 
+	if path, ok := flagValue("--launch"); ok {
+		runLaunch(path)
+	}
+
+	if addr, ok := flagValue("--metrics-addr"); ok {
+		// Listen before the TUI takes over the terminal, so a busy port is
+		// reported plainly.
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if slices.Contains(os.Args[1:], "--headless") {
+			if err := agent.ServeMetrics(ctx, ln); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		// Served for as long as the TUI runs.
+		go agent.ServeMetrics(ctx, ln)
+	}
+
 	tmux := os.Getenv("TMUX")
 	sessionID := filepath.Base(tmux)
 
-	p := tea.NewProgram(tui.NewModel(sessionID), tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := p.Run(); err != nil {
+	opts := tui.Options{
+		CurrentSessionOnly: slices.Contains(os.Args[1:], "--current-session"),
+		Picker:             slices.Contains(os.Args[1:], "--picker"),
+		PrintTarget:        slices.Contains(os.Args[1:], "--print-target"),
+	}
+	progOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if opts.PrintTarget {
+		// stdout is for the result (usually a pipe or $(...)); draw on the
+		// terminal directly.
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		defer tty.Close()
+		lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(tty))
+		progOpts = append(progOpts, tea.WithOutput(tty))
+	}
+	p := tea.NewProgram(tui.NewModel(sessionID, opts), progOpts...)
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	// Run has restored the terminal by now, so the target lands on a clean
+	// stdout.
+	if m, ok := final.(tui.Model); ok && m.Selected() != "" {
+		fmt.Println(m.Selected())
+	} else if opts.PrintTarget {
+		os.Exit(1)
+	}
+}
+
+// flagValue returns the value of a "--name value" or "--name=value" flag.
+func flagValue(name string) (string, bool) {
+	args := os.Args[1:]
+	for i, arg := range args {
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v, true
+		}
+		if arg == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// runLaunch starts every agent of the layout file at path in a new tmux
+// window, reporting each one on stderr. Agents that fail to start (e.g. a
+// missing directory) are reported and skipped; the TUI opens either way.
+func runLaunch(path string) {
+	layout, err := config.LoadLayout(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	for _, a := range layout.Agents {
+		if info, err := os.Stat(a.Dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "skipped %s: not a directory\n", a.Dir)
+			continue
+		}
+		target, err := agent.NewAgentPane(layout.Session, a.Dir, a.Command)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipped %s: %v\n", a.Dir, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "started %s in %s (%s)\n", a.Command, a.Dir, target)
+	}
+}
+
+// runTestDetect reports what status detection makes of a captured frame, read
+// from the file after --test-detect or from stdin:
+//
+//	tmux capture-pane -p -t main:2 | agent-mux --test-detect --provider claude
+func runTestDetect() {
+	var content []byte
+	var err error
+	if path, ok := flagValue("--test-detect"); ok && !strings.HasPrefix(path, "-") {
+		content, err = os.ReadFile(path)
+	} else {
+		content, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	name, _ := flagValue("--provider")
+	agent.ExplainDetection(os.Stdout, name, content)
 }
 
 func runBenchLoop() {
 	// Simulate one full refresh cycle (what runs every 2s in the runtime loop).
-	// 1. ListPanes (tmux + ps + history + attention heuristics, parallel)
+	// 1. ListPanes (tmux + ps + capture + attention heuristics, parallel)
 	// 2. CapturePane (preview load)
 
 	t0 := time.Now()